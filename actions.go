@@ -0,0 +1,358 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/base"
+	"go.viam.com/rdk/components/board"
+	"go.viam.com/rdk/components/button"
+	"go.viam.com/rdk/components/gripper"
+	"go.viam.com/rdk/components/motor"
+	toggleswitch "go.viam.com/rdk/components/switch"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+)
+
+// keyAction is a method a key can call on a resource when pressed
+type keyAction struct {
+	// checkArgs validates the args in the config, before we know what the resource is
+	checkArgs func(args []interface{}) error
+	run       func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error)
+}
+
+// keyActions is keyed by normalized method name, see normalizeMethod
+var keyActions = map[string]keyAction{}
+var keyActionNames = []string{}
+
+func registerKeyAction(method string, a keyAction) {
+	keyActions[normalizeMethod(method)] = a
+	keyActionNames = append(keyActionNames, method)
+}
+
+// normalizeMethod makes do_command, DoCommand and docommand the same
+func normalizeMethod(method string) string {
+	return strings.ToLower(strings.ReplaceAll(method, "_", ""))
+}
+
+func findKeyAction(method string) (keyAction, error) {
+	a, ok := keyActions[normalizeMethod(method)]
+	if !ok {
+		names := slices.Clone(keyActionNames)
+		slices.Sort(names)
+		return keyAction{}, fmt.Errorf("unsupported method %s, supported: %s", method, strings.Join(names, ", "))
+	}
+	return a, nil
+}
+
+func asResource[T any](r resource.Resource, method string) (T, error) {
+	t, ok := r.(T)
+	if !ok {
+		return t, fmt.Errorf("%s is a %T, it has no %s", r.Name().ShortName(), r, method)
+	}
+	return t, nil
+}
+
+func argNumber(args []interface{}, i int) (float64, error) {
+	if i >= len(args) {
+		return 0, fmt.Errorf("missing arg %d", i)
+	}
+	switch v := args[i].(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("arg %d should be a number, not %T", i, args[i])
+}
+
+func argNumbers(args []interface{}, i int) ([]float64, error) {
+	if i >= len(args) {
+		return nil, fmt.Errorf("missing arg %d", i)
+	}
+	l, ok := args[i].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("arg %d should be a list of numbers, not %T", i, args[i])
+	}
+	res := []float64{}
+	for j := range l {
+		n, err := argNumber(l, j)
+		if err != nil {
+			return nil, fmt.Errorf("arg %d: %w", i, err)
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+// numberArgs checks for exactly n numbers
+func numberArgs(n int) func(args []interface{}) error {
+	return func(args []interface{}) error {
+		if len(args) != n {
+			return fmt.Errorf("need %d number args, got %d", n, len(args))
+		}
+		for i := range args {
+			if _, err := argNumber(args, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+type stopper interface {
+	Stop(ctx context.Context, extra map[string]interface{}) error
+}
+
+func init() {
+	registerKeyAction("do_command", keyAction{
+		checkArgs: func(args []interface{}) error {
+			if len(args) > 1 {
+				return fmt.Errorf("do_command takes at most 1 arg, got %d", len(args))
+			}
+			if len(args) == 1 {
+				if _, ok := args[0].(map[string]interface{}); !ok {
+					return fmt.Errorf("do_command arg should be a map, not %T", args[0])
+				}
+			}
+			return nil
+		},
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			cmd := map[string]interface{}{}
+			if len(args) > 0 {
+				cmd = args[0].(map[string]interface{})
+			}
+			return r.DoCommand(ctx, cmd)
+		},
+	})
+
+	registerKeyAction("set_position", keyAction{
+		checkArgs: numberArgs(1),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			s, err := asResource[toggleswitch.Switch](r, "set_position")
+			if err != nil {
+				return nil, err
+			}
+			n, err := argNumber(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			return nil, s.SetPosition(ctx, uint32(n), nil)
+		},
+	})
+
+	// motor, base, arm and gripper
+	registerKeyAction("stop", keyAction{
+		checkArgs: numberArgs(0),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			s, err := asResource[stopper](r, "stop")
+			if err != nil {
+				return nil, err
+			}
+			return nil, s.Stop(ctx, nil)
+		},
+	})
+
+	// motor takes [power], base takes [linear, angular], all -1 -> 1
+	registerKeyAction("set_power", keyAction{
+		checkArgs: func(args []interface{}) error {
+			if len(args) == 2 {
+				return numberArgs(2)(args)
+			}
+			return numberArgs(1)(args)
+		},
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			// the config is checked before we know which it is, so the number of args is checked here
+			switch x := r.(type) {
+			case motor.Motor:
+				if len(args) != 1 {
+					return nil, fmt.Errorf("%s is a motor, set_power takes [ power ], got %d args", r.Name().ShortName(), len(args))
+				}
+				p, err := argNumber(args, 0)
+				if err != nil {
+					return nil, err
+				}
+				return nil, x.SetPower(ctx, p, nil)
+			case base.Base:
+				if len(args) != 2 {
+					return nil, fmt.Errorf("%s is a base, set_power takes [ linear, angular ], got %d args", r.Name().ShortName(), len(args))
+				}
+				linear, err := argNumber(args, 0)
+				if err != nil {
+					return nil, err
+				}
+				angular, err := argNumber(args, 1)
+				if err != nil {
+					return nil, err
+				}
+				return nil, x.SetPower(ctx, r3.Vector{Y: linear}, r3.Vector{Z: angular}, nil)
+			}
+			return nil, fmt.Errorf("%s is a %T, it has no set_power", r.Name().ShortName(), r)
+		},
+	})
+
+	// [rpm, revolutions]
+	registerKeyAction("go_for", keyAction{
+		checkArgs: numberArgs(2),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			m, err := asResource[motor.Motor](r, "go_for")
+			if err != nil {
+				return nil, err
+			}
+			rpm, _ := argNumber(args, 0)
+			revs, _ := argNumber(args, 1)
+			return nil, m.GoFor(ctx, rpm, revs, nil)
+		},
+	})
+
+	// [rpm, position in revolutions]
+	registerKeyAction("go_to", keyAction{
+		checkArgs: numberArgs(2),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			m, err := asResource[motor.Motor](r, "go_to")
+			if err != nil {
+				return nil, err
+			}
+			rpm, _ := argNumber(args, 0)
+			pos, _ := argNumber(args, 1)
+			return nil, m.GoTo(ctx, rpm, pos, nil)
+		},
+	})
+
+	// [mm/s, degrees/s]
+	registerKeyAction("set_velocity", keyAction{
+		checkArgs: numberArgs(2),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			b, err := asResource[base.Base](r, "set_velocity")
+			if err != nil {
+				return nil, err
+			}
+			linear, _ := argNumber(args, 0)
+			angular, _ := argNumber(args, 1)
+			return nil, b.SetVelocity(ctx, r3.Vector{Y: linear}, r3.Vector{Z: angular}, nil)
+		},
+	})
+
+	// [mm, mm/s]
+	registerKeyAction("move_straight", keyAction{
+		checkArgs: numberArgs(2),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			b, err := asResource[base.Base](r, "move_straight")
+			if err != nil {
+				return nil, err
+			}
+			mm, _ := argNumber(args, 0)
+			speed, _ := argNumber(args, 1)
+			return nil, b.MoveStraight(ctx, int(mm), speed, nil)
+		},
+	})
+
+	// [degrees, degrees/s]
+	registerKeyAction("spin", keyAction{
+		checkArgs: numberArgs(2),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			b, err := asResource[base.Base](r, "spin")
+			if err != nil {
+				return nil, err
+			}
+			angle, _ := argNumber(args, 0)
+			speed, _ := argNumber(args, 1)
+			return nil, b.Spin(ctx, angle, speed, nil)
+		},
+	})
+
+	registerKeyAction("open", keyAction{
+		checkArgs: numberArgs(0),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			g, err := asResource[gripper.Gripper](r, "open")
+			if err != nil {
+				return nil, err
+			}
+			return nil, g.Open(ctx, nil)
+		},
+	})
+
+	registerKeyAction("grab", keyAction{
+		checkArgs: numberArgs(0),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			g, err := asResource[gripper.Gripper](r, "grab")
+			if err != nil {
+				return nil, err
+			}
+			return g.Grab(ctx, nil)
+		},
+	})
+
+	registerKeyAction("push", keyAction{
+		checkArgs: numberArgs(0),
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			b, err := asResource[button.Button](r, "push")
+			if err != nil {
+				return nil, err
+			}
+			return nil, b.Push(ctx, nil)
+		},
+	})
+
+	// [[joint degrees, ...]]
+	registerKeyAction("move_to_joint_positions", keyAction{
+		checkArgs: func(args []interface{}) error {
+			if len(args) != 1 {
+				return fmt.Errorf("need 1 arg, a list of joint positions in degrees")
+			}
+			_, err := argNumbers(args, 0)
+			return err
+		},
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			a, err := asResource[arm.Arm](r, "move_to_joint_positions")
+			if err != nil {
+				return nil, err
+			}
+			degrees, err := argNumbers(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			inputs := []float64{}
+			for _, d := range degrees {
+				inputs = append(inputs, utils.DegToRad(d))
+			}
+			return nil, a.MoveToJointPositions(ctx, inputs, nil)
+		},
+	})
+
+	// [pin name, high]
+	registerKeyAction("set_gpio", keyAction{
+		checkArgs: func(args []interface{}) error {
+			if len(args) != 2 {
+				return fmt.Errorf("need 2 args, pin name and high")
+			}
+			if _, ok := args[0].(string); !ok {
+				return fmt.Errorf("pin name should be a string, not %T", args[0])
+			}
+			if _, ok := args[1].(bool); !ok {
+				return fmt.Errorf("high should be a bool, not %T", args[1])
+			}
+			return nil
+		},
+		run: func(ctx context.Context, r resource.Resource, args []interface{}) (interface{}, error) {
+			b, err := asResource[board.Board](r, "set_gpio")
+			if err != nil {
+				return nil, err
+			}
+			pin, err := b.GPIOPinByName(args[0].(string))
+			if err != nil {
+				return nil, err
+			}
+			return nil, pin.Set(ctx, args[1].(bool), nil)
+		},
+	})
+}
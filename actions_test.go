@@ -0,0 +1,83 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+)
+
+func TestKeyActionValidate(t *testing.T) {
+	for _, kc := range []KeyConfig{
+		{Component: "m", Method: "set_power", Args: []interface{}{.5}},
+		{Component: "m", Method: "SetPower", Args: []interface{}{.5, .2}},
+		{Component: "m", Method: "go_for", Args: []interface{}{60.0, 2.0}},
+		{Component: "m", Method: "stop"},
+		{Component: "a", Method: "move_to_joint_positions", Args: []interface{}{[]interface{}{0.0, 90.0, 45.0}}},
+		{Component: "b", Method: "set_gpio", Args: []interface{}{"11", true}},
+		{Component: "g", Method: "grab"},
+	} {
+		test.That(t, kc.Validate(), test.ShouldBeNil)
+	}
+
+	for _, kc := range []KeyConfig{
+		{Component: "m", Method: "fly"},
+		{Component: "m", Method: "set_power"},
+		{Component: "m", Method: "set_power", Args: []interface{}{"fast"}},
+		{Component: "m", Method: "set_power", Args: []interface{}{.5, .2, .1}},
+		{Component: "m", Method: "stop", Args: []interface{}{1.0}},
+		{Component: "a", Method: "move_to_joint_positions", Args: []interface{}{1.0}},
+		{Component: "b", Method: "set_gpio", Args: []interface{}{11.0, true}},
+		{Component: "x", Method: "do_command", Args: []interface{}{"nope"}},
+	} {
+		test.That(t, kc.Validate(), test.ShouldNotBeNil)
+	}
+}
+
+func TestKeyActionRun(t *testing.T) {
+	ctx := context.Background()
+
+	power := 0.0
+	m := inject.NewMotor("m")
+	m.SetPowerFunc = func(ctx context.Context, powerPct float64, extra map[string]interface{}) error {
+		power = powerPct
+		return nil
+	}
+
+	linear := r3.Vector{}
+	b := inject.NewBase("b")
+	b.SetPowerFunc = func(ctx context.Context, l, a r3.Vector, extra map[string]interface{}) error {
+		linear = l
+		return nil
+	}
+
+	a, err := findKeyAction("set_power")
+	test.That(t, err, test.ShouldBeNil)
+
+	_, err = a.run(ctx, m, []interface{}{.4})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, power, test.ShouldEqual, .4)
+
+	_, err = a.run(ctx, b, []interface{}{.3, 0.0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, linear, test.ShouldResemble, r3.Vector{Y: .3})
+
+	// the wrong number of args for what the resource is
+	_, err = a.run(ctx, m, []interface{}{.2, .1})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "motor")
+	test.That(t, power, test.ShouldEqual, .4)
+
+	_, err = a.run(ctx, b, []interface{}{.2})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "base")
+	test.That(t, linear, test.ShouldResemble, r3.Vector{Y: .3})
+
+	a, err = findKeyAction("Grab")
+	test.That(t, err, test.ShouldBeNil)
+	_, err = a.run(ctx, m, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -20,7 +20,7 @@ import (
 var assetsFS embed.FS
 
 var assetImages map[string]image.Image
-var assetFonts map[string]*truetype.Font
+var assetFonts map[string]*fontFile
 
 func init() {
 	var err error
@@ -84,8 +84,8 @@ func loadImages() (map[string]image.Image, error) {
 	return imageMap, nil
 }
 
-func loadFonts() (map[string]*truetype.Font, error) {
-	fontMap := make(map[string]*truetype.Font)
+func loadFonts() (map[string]*fontFile, error) {
+	fontMap := make(map[string]*fontFile)
 
 	supportedExts := map[string]bool{
 		".ttf": true,
@@ -117,7 +117,7 @@ func loadFonts() (map[string]*truetype.Font, error) {
 			return fmt.Errorf("failed to read font data %s: %w", path, err)
 		}
 
-		font, err := truetype.Parse(data)
+		font, err := parseFont(data)
 		if err != nil {
 			return fmt.Errorf("failed to parse font %s: %w", path, err)
 		}
@@ -135,9 +135,13 @@ func loadFonts() (map[string]*truetype.Font, error) {
 	return fontMap, nil
 }
 
-// GetFont returns a font by filename, or nil if not found
+// GetFont returns a font by filename, or nil if not found or freetype can't read it
 func GetFont(filename string) *truetype.Font {
-	return assetFonts[filename]
+	ff, ok := assetFonts[filename]
+	if !ok {
+		return nil
+	}
+	return ff.tt
 }
 
 // LoadExternalFont loads a font from an absolute file path and adds it to assetFonts
@@ -147,7 +151,7 @@ func LoadExternalFont(path string) error {
 		return fmt.Errorf("failed to read font file %s: %w", path, err)
 	}
 
-	font, err := truetype.Parse(data)
+	font, err := parseFont(data)
 	if err != nil {
 		return fmt.Errorf("failed to parse font %s: %w", path, err)
 	}
@@ -27,6 +27,7 @@ func realMain() error {
 	configFile := flag.String("config", "config file", "")
 	host := flag.String("host", "", "")
 	sleep := flag.Int("sleep", 10, "")
+	virtual := flag.Bool("virtual", false, "serve a virtual deck on a web page instead of using usb")
 
 	flag.Parse()
 
@@ -74,7 +75,15 @@ func realMain() error {
 		}
 	}
 
-	sd, err := viamstreamdeck.NewStreamDeck(ctx, generic.Named("foo"), deps, nil, conf, logger)
+	var ms *viamstreamdeck.ModelSetup
+	if *virtual {
+		ms, err = viamstreamdeck.VirtualModel(conf)
+		if err != nil {
+			return err
+		}
+	}
+
+	sd, err := viamstreamdeck.NewStreamDeck(ctx, generic.Named("foo"), deps, ms, conf, logger)
 	if err != nil {
 		return err
 	}
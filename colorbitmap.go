@@ -0,0 +1,279 @@
+package viamstreamdeck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"sync"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// colorBitmaps are the png glyphs of a color emoji font, from its CBLC and CBDT tables
+type colorBitmaps struct {
+	ppem   int // size of the strike the glyphs are drawn at
+	cbdt   []byte
+	glyphs map[sfnt.GlyphIndex]colorGlyph
+
+	lock    sync.Mutex
+	decoded map[sfnt.GlyphIndex]*colorBitmap
+}
+
+// colorGlyph is where a glyph's bitmap is in the CBDT table
+type colorGlyph struct {
+	format uint16
+	offset uint32
+	length uint32
+}
+
+// colorBitmap is a decoded glyph, with metrics in pixels of its strike
+type colorBitmap struct {
+	img      *image.RGBA
+	bearingX int
+	bearingY int
+}
+
+var errShortTable = errors.New("font table is too short")
+
+type tableReader []byte
+
+func (t tableReader) u8(off int) (int, error) {
+	if off < 0 || off+1 > len(t) {
+		return 0, errShortTable
+	}
+	return int(t[off]), nil
+}
+
+func (t tableReader) u16(off int) (int, error) {
+	if off < 0 || off+2 > len(t) {
+		return 0, errShortTable
+	}
+	return int(binary.BigEndian.Uint16(t[off:])), nil
+}
+
+func (t tableReader) u32(off int) (int, error) {
+	if off < 0 || off+4 > len(t) {
+		return 0, errShortTable
+	}
+	return int(binary.BigEndian.Uint32(t[off:])), nil
+}
+
+// fontTable returns the table with tag from the font file data, nil if there isn't one
+func fontTable(data []byte, tag string) ([]byte, error) {
+	t := tableReader(data)
+	n, err := t.u16(4)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errShortTable
+		}
+		if string(data[rec:rec+4]) != tag {
+			continue
+		}
+		off, _ := t.u32(rec + 8)
+		length, _ := t.u32(rec + 12)
+		if off+length > len(data) {
+			return nil, fmt.Errorf("%s: %w", tag, errShortTable)
+		}
+		return data[off : off+length], nil
+	}
+	return nil, nil
+}
+
+// parseColorBitmaps reads the biggest strike of a font's color bitmaps, it returns nil if the font has none
+func parseColorBitmaps(data []byte) (*colorBitmaps, error) {
+	cblc, err := fontTable(data, "CBLC")
+	if err != nil || cblc == nil {
+		return nil, err
+	}
+	cbdt, err := fontTable(data, "CBDT")
+	if err != nil {
+		return nil, err
+	}
+	if cbdt == nil {
+		return nil, fmt.Errorf("font has CBLC but no CBDT")
+	}
+
+	t := tableReader(cblc)
+	numSizes, err := t.u32(4)
+	if err != nil {
+		return nil, err
+	}
+
+	// the biggest strike scales down best
+	best, bestPpem := -1, 0
+	for i := 0; i < numSizes; i++ {
+		ppem, err := t.u8(8 + 48*i + 45)
+		if err != nil {
+			return nil, err
+		}
+		if ppem > bestPpem {
+			best, bestPpem = i, ppem
+		}
+	}
+	if best < 0 {
+		return nil, nil
+	}
+
+	cb := &colorBitmaps{ppem: bestPpem, cbdt: cbdt, glyphs: map[sfnt.GlyphIndex]colorGlyph{}, decoded: map[sfnt.GlyphIndex]*colorBitmap{}}
+
+	size := 8 + 48*best
+	arrayOffset, _ := t.u32(size)
+	numSubTables, err := t.u32(size + 8)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < numSubTables; i++ {
+		entry := arrayOffset + 8*i
+		first, _ := t.u16(entry)
+		last, _ := t.u16(entry + 2)
+		extra, err := t.u32(entry + 4)
+		if err != nil {
+			return nil, err
+		}
+		err = cb.addSubTable(t, arrayOffset+extra, first, last)
+		if err != nil {
+			return nil, fmt.Errorf("CBLC subtable %d: %w", i, err)
+		}
+	}
+	return cb, nil
+}
+
+// addSubTable records where the glyphs from first to last are, for the index formats of the spec
+func (cb *colorBitmaps) addSubTable(t tableReader, at, first, last int) error {
+	indexFormat, _ := t.u16(at)
+	imageFormat, _ := t.u16(at + 2)
+	dataOffset, err := t.u32(at + 4)
+	if err != nil {
+		return err
+	}
+	add := func(glyph, offset, length int) {
+		cb.glyphs[sfnt.GlyphIndex(glyph)] = colorGlyph{uint16(imageFormat), uint32(dataOffset + offset), uint32(length)}
+	}
+
+	switch indexFormat {
+	case 1, 3:
+		width := 4
+		if indexFormat == 3 {
+			width = 2
+		}
+		read := t.u32
+		if width == 2 {
+			read = t.u16
+		}
+		for g := first; g <= last; g++ {
+			i := g - first
+			start, _ := read(at + 8 + width*i)
+			end, err := read(at + 8 + width*(i+1))
+			if err != nil {
+				return err
+			}
+			if end > start {
+				add(g, start, end-start)
+			}
+		}
+	case 2:
+		imageSize, err := t.u32(at + 8)
+		if err != nil {
+			return err
+		}
+		for g := first; g <= last; g++ {
+			add(g, (g-first)*imageSize, imageSize)
+		}
+	case 4:
+		n, err := t.u32(at + 8)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			g, _ := t.u16(at + 12 + 4*i)
+			start, _ := t.u16(at + 12 + 4*i + 2)
+			end, err := t.u16(at + 12 + 4*(i+1) + 2)
+			if err != nil {
+				return err
+			}
+			add(g, start, end-start)
+		}
+	case 5:
+		imageSize, _ := t.u32(at + 8)
+		n, err := t.u32(at + 8 + 4 + 8)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			g, err := t.u16(at + 24 + 2*i)
+			if err != nil {
+				return err
+			}
+			add(g, i*imageSize, imageSize)
+		}
+	default:
+		return fmt.Errorf("unknown index format %d", indexFormat)
+	}
+	return nil
+}
+
+// glyph returns the decoded bitmap for x, nil if the font has none for it
+func (cb *colorBitmaps) glyph(x sfnt.GlyphIndex) (*colorBitmap, error) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if b, ok := cb.decoded[x]; ok {
+		return b, nil
+	}
+	g, ok := cb.glyphs[x]
+	if !ok {
+		return nil, nil
+	}
+
+	b, err := cb.decode(g)
+	if err != nil {
+		return nil, fmt.Errorf("glyph %d: %w", x, err)
+	}
+	cb.decoded[x] = b
+	return b, nil
+}
+
+// decode reads the png glyph formats, 17 with small metrics and 18 with big metrics
+func (cb *colorBitmaps) decode(g colorGlyph) (*colorBitmap, error) {
+	if int(g.offset+g.length) > len(cb.cbdt) {
+		return nil, errShortTable
+	}
+	t := tableReader(cb.cbdt[g.offset : g.offset+g.length])
+
+	var metricsLen int
+	switch g.format {
+	case 17:
+		metricsLen = 5
+	case 18:
+		metricsLen = 8
+	default:
+		return nil, fmt.Errorf("unsupported image format %d", g.format)
+	}
+	bx, _ := t.u8(2)
+	by, _ := t.u8(3)
+	pngLen, err := t.u32(metricsLen)
+	if err != nil {
+		return nil, err
+	}
+	start := metricsLen + 4
+	if start+pngLen > len(t) {
+		return nil, errShortTable
+	}
+
+	img, err := png.Decode(bytes.NewReader(t[start : start+pngLen]))
+	if err != nil {
+		return nil, err
+	}
+	rgba := image.NewRGBA(image.Rectangle{Max: img.Bounds().Size()})
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	return &colorBitmap{img: rgba, bearingX: int(int8(bx)), bearingY: int(int8(by))}, nil
+}
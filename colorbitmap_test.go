@@ -0,0 +1,91 @@
+package viamstreamdeck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"go.viam.com/test"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// colorFont is a font file with only CBLC and CBDT tables, glyph 3 is a red png
+func colorFont(t *testing.T, ppem uint8) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 2; y++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	var pngData bytes.Buffer
+	test.That(t, png.Encode(&pngData, img), test.ShouldBeNil)
+
+	be := binary.BigEndian
+
+	// one glyph in format 17: small metrics, length, png
+	cbdt := []byte{0, 3, 0, 0}
+	cbdt = append(cbdt, 2, 4, 1, 9, 5)
+	cbdt = be.AppendUint32(cbdt, uint32(pngData.Len()))
+	cbdt = append(cbdt, pngData.Bytes()...)
+
+	cblc := []byte{0, 3, 0, 0}
+	cblc = be.AppendUint32(cblc, 1)
+	size := make([]byte, 48)
+	be.PutUint32(size[0:], 56) // index subtable array
+	be.PutUint32(size[8:], 1)
+	size[45] = ppem
+	cblc = append(cblc, size...)
+	// array entry for glyph 3, then an index format 1 subtable of image format 17 at offset 4 of CBDT
+	cblc = be.AppendUint16(cblc, 3)
+	cblc = be.AppendUint16(cblc, 3)
+	cblc = be.AppendUint32(cblc, 8)
+	cblc = be.AppendUint16(cblc, 1)
+	cblc = be.AppendUint16(cblc, 17)
+	cblc = be.AppendUint32(cblc, 4)
+	cblc = be.AppendUint32(cblc, 0)
+	cblc = be.AppendUint32(cblc, uint32(len(cbdt)-4))
+
+	data := []byte{0, 1, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0}
+	off := 12 + 16*2
+	for _, tbl := range []struct {
+		tag  string
+		data []byte
+	}{{"CBDT", cbdt}, {"CBLC", cblc}} {
+		data = append(data, tbl.tag...)
+		data = be.AppendUint32(data, 0)
+		data = be.AppendUint32(data, uint32(off))
+		data = be.AppendUint32(data, uint32(len(tbl.data)))
+		off += len(tbl.data)
+	}
+	data = append(data, cbdt...)
+	return append(data, cblc...)
+}
+
+func TestColorBitmaps(t *testing.T) {
+	cb, err := parseColorBitmaps(colorFont(t, 20))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cb.ppem, test.ShouldEqual, 20)
+
+	b, err := cb.glyph(3)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, b.img.Bounds(), test.ShouldResemble, image.Rect(0, 0, 4, 2))
+	test.That(t, b.img.RGBAAt(1, 1), test.ShouldResemble, color.RGBA{255, 0, 0, 255})
+	test.That(t, b.bearingX, test.ShouldEqual, 1)
+	test.That(t, b.bearingY, test.ShouldEqual, 9)
+
+	b, err = cb.glyph(sfnt.GlyphIndex(4))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, b, test.ShouldBeNil)
+
+	// fonts without color bitmaps have none
+	cb, err = parseColorBitmaps([]byte{0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cb, test.ShouldBeNil)
+
+	_, err = parseColorBitmaps(colorFont(t, 20)[:60])
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -3,6 +3,8 @@ package viamstreamdeck
 import (
 	"fmt"
 	"image/color"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/dh1tw/streamdeck"
@@ -22,78 +24,101 @@ func snakeToCamel(s string) string {
 }
 
 func getColor(want, def string) color.Color {
-	c, ok := colornames.Map[want]
-	if ok {
+	c, err := parseColor(want)
+	if err == nil {
 		return c
 	}
 
-	c, ok = colornames.Map[def]
-	if ok {
+	c, err = parseColor(def)
+	if err == nil {
 		return c
 	}
 
 	panic(fmt.Errorf("default color didn't work [%s]", def))
 }
 
-func (ms *ModelSetup) SimpleText(text string, clr string, textFont *string) []streamdeck.TextLine {
-	return ms.simpleText(text, clr, 20, textFont)
-}
-
-func (ms *ModelSetup) simpleText(text string, clr string, fontSize float64, textFont *string) []streamdeck.TextLine {
-	if fontSize <= 0 {
-		panic(fontSize)
+// parseColor understands css color names, #RGB, #RRGGBB, #RRGGBBAA, rgb(r, g, b) and rgba(r, g, b, a) with a from 0 to 1
+func parseColor(s string) (color.Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := colornames.Map[s]; ok {
+		return c, nil
 	}
 
-	maxLine := int(1.6 * float64(ms.Conf.ButtonSize) / fontSize)
-
-	lines := []string{""}
-
-	for _, s := range strings.Split(text, " ") {
-
-		if len(s) >= maxLine && fontSize > 4 {
-			return ms.simpleText(text, clr, fontSize-2, textFont)
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
 		}
-
-		last := lines[len(lines)-1]
-
-		if last != "" && (len(last)+1+len(s)) > maxLine {
-			lines = append(lines, s)
-		} else {
-			if last == "" {
-				last = s
-			} else {
-				last = last + " " + s
-			}
-			lines[len(lines)-1] = last
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		if len(hex) != 8 {
+			return nil, fmt.Errorf("bad color %s, need #RGB, #RRGGBB or #RRGGBBAA", s)
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad color %s: %w", s, err)
 		}
+		return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
 	}
 
-	if fontSize > 4 && len(lines) >= ms.Conf.ButtonSize/int(fontSize) {
-		return ms.simpleText(text, clr, fontSize-2, textFont)
+	args, alpha := "", false
+	if a, ok := strings.CutPrefix(s, "rgba("); ok {
+		args, alpha = a, true
+	} else if a, ok := strings.CutPrefix(s, "rgb("); ok {
+		args = a
+	} else {
+		return nil, fmt.Errorf("unknown color %s", s)
+	}
+	args, ok := strings.CutSuffix(args, ")")
+	if !ok {
+		return nil, fmt.Errorf("bad color %s, missing )", s)
 	}
 
-	tls := []streamdeck.TextLine{}
-
-	for idx, l := range lines {
-		tl := streamdeck.TextLine{
-			Text:      l,
-			PosX:      5,
-			PosY:      (idx * int(fontSize)),
-			FontSize:  fontSize,
-			FontColor: getColor(clr, "white"),
+	parts := strings.Split(args, ",")
+	if (alpha && len(parts) != 4) || (!alpha && len(parts) != 3) {
+		return nil, fmt.Errorf("bad color %s, wrong number of values", s)
+	}
+	rgb := [3]uint8{}
+	for i, p := range parts[:3] {
+		v, err := strconv.ParseUint(strings.TrimSpace(p), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("bad color %s, values are 0 to 255", s)
 		}
-		if textFont != nil {
-			tl.Font = GetFont(*textFont)
+		rgb[i] = uint8(v)
+	}
+	c := color.NRGBA{rgb[0], rgb[1], rgb[2], 0xff}
+	if alpha {
+		a, err := strconv.ParseFloat(strings.TrimSpace(parts[3]), 64)
+		if err != nil || a < 0 || a > 1 {
+			return nil, fmt.Errorf("bad color %s, alpha is 0 to 1", s)
 		}
-		tls = append(tls, tl)
+		c.A = uint8(math.Round(a * 255))
 	}
+	return c, nil
+}
+
+func (ms *ModelSetup) SimpleText(text string, clr string, textFont *string) []streamdeck.TextLine {
+	return ms.SimpleTextFonts(text, clr, fontList(textFont))
+}
 
-	return tls
+// SimpleTextFonts is SimpleText drawing each character with the first of fonts that has it
+func (ms *ModelSetup) SimpleTextFonts(text string, clr string, fonts []string) []streamdeck.TextLine {
+	return ms.LayoutText(text, clr, fonts, nil)
 }
 
 func (ms *ModelSetup) SimpleTextButton(text string, bgColor, textClr string, textFont *string) streamdeck.TextButton {
-	return streamdeck.TextButton{
-		Lines:   ms.SimpleText(text, textClr, textFont),
-		BgColor: getColor(bgColor, "black"),
+	return ms.SimpleTextButtonFonts(text, bgColor, textClr, fontList(textFont))
+}
+
+// SimpleTextButtonFonts is SimpleTextButton drawing each character with the first of fonts that has it
+func (ms *ModelSetup) SimpleTextButtonFonts(text string, bgColor, textClr string, fonts []string) streamdeck.TextButton {
+	return ms.TextButton(text, bgColor, textClr, fonts, nil)
+}
+
+// fontList is a single font as a list, nil for the default
+func fontList(textFont *string) []string {
+	if textFont == nil {
+		return nil
 	}
+	return []string{*textFont}
 }
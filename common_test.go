@@ -9,3 +9,25 @@ import (
 func TestSnakeToCamel(t *testing.T) {
 	test.That(t, snakeToCamel("foo_bar"), test.ShouldEqual, "FooBar")
 }
+
+func TestSimpleTextAllModels(t *testing.T) {
+	for _, ms := range Models {
+		t.Run(ms.Model.Name, func(t *testing.T) {
+			lines := ms.SimpleText("move arm to home position", "white", nil)
+			test.That(t, len(lines), test.ShouldBeGreaterThan, 1)
+			for _, l := range lines {
+				test.That(t, l.PosY+int(l.FontSize), test.ShouldBeLessThanOrEqualTo, ms.Conf.ButtonSize)
+			}
+
+			fd := NewFakeDeck(ms.Conf)
+			last := ms.Conf.NumButtons() - 1
+			test.That(t, fd.WriteText(last, ms.SimpleTextButton("hi", "red", "white", nil)), test.ShouldBeNil)
+			test.That(t, fd.WriteText(last+1, ms.SimpleTextButton("hi", "red", "white", nil)), test.ShouldNotBeNil)
+
+			// a single font is the same as a list of it
+			mono := "mono.ttf"
+			test.That(t, ms.SimpleText("hi", "white", &mono), test.ShouldResemble, ms.SimpleTextFonts("hi", "white", []string{mono}))
+			test.That(t, ms.SimpleTextButton("hi", "red", "white", &mono), test.ShouldResemble, ms.SimpleTextButtonFonts("hi", "red", "white", []string{mono}))
+		})
+	}
+}
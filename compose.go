@@ -0,0 +1,188 @@
+package viamstreamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// ImageStyle is how a key's image is fit to it
+type ImageStyle struct {
+	Fit     string `json:"fit,omitempty"`     // stretch (default), contain or cover
+	Align   string `json:"align,omitempty"`   // left, center or right, defaults to center
+	VAlign  string `json:"valign,omitempty"`  // top, middle or bottom, defaults to middle
+	Padding int    `json:"padding,omitempty"` // pixels between the image and the edge of the key
+}
+
+func (is *ImageStyle) Validate() error {
+	switch is.Fit {
+	case "", "stretch", "contain", "cover":
+	default:
+		return fmt.Errorf("unknown fit %s, can be stretch, contain or cover", is.Fit)
+	}
+	switch is.Align {
+	case "", "left", "center", "right":
+	default:
+		return fmt.Errorf("unknown align %s, can be left, center or right", is.Align)
+	}
+	switch is.VAlign {
+	case "", "top", "middle", "bottom":
+	default:
+		return fmt.Errorf("unknown valign %s, can be top, middle or bottom", is.VAlign)
+	}
+	if is.Padding < 0 {
+		return fmt.Errorf("padding can't be negative")
+	}
+	return nil
+}
+
+// checkSize makes sure the padding leaves room for the image on a size x size key
+func (is *ImageStyle) checkSize(size int) error {
+	if 2*is.Padding >= size {
+		return fmt.Errorf("padding %d leaves no room for the image on a %d pixel key", is.Padding, size)
+	}
+	return nil
+}
+
+// BadgeConfig is a dot or short label in a corner of a key, like a count of notifications
+type BadgeConfig struct {
+	Text      string `json:"text,omitempty"` // without text the badge is a dot
+	Color     string `json:"color,omitempty"`
+	TextColor string `json:"text_color,omitempty"`
+	Corner    string `json:"corner,omitempty"` // top_right (default), top_left, bottom_right or bottom_left
+}
+
+func (bc *BadgeConfig) Validate() error {
+	switch bc.Corner {
+	case "", "top_right", "top_left", "bottom_right", "bottom_left":
+	default:
+		return fmt.Errorf("unknown corner %s, can be top_right, top_left, bottom_right or bottom_left", bc.Corner)
+	}
+	return nil
+}
+
+// fitImage returns where img goes in a size x size key and how big it is drawn
+func fitImage(img image.Image, size int, style *ImageStyle) image.Rectangle {
+	if style == nil {
+		style = &ImageStyle{}
+	}
+	inner := size - 2*style.Padding
+	w, h := inner, inner
+
+	b := img.Bounds()
+	if style.Fit == "contain" || style.Fit == "cover" {
+		sx, sy := float64(inner)/float64(b.Dx()), float64(inner)/float64(b.Dy())
+		scale := math.Min(sx, sy)
+		if style.Fit == "cover" {
+			scale = math.Max(sx, sy)
+		}
+		w = max(1, int(math.Round(float64(b.Dx())*scale)))
+		h = max(1, int(math.Round(float64(b.Dy())*scale)))
+	}
+
+	x := style.Padding + (inner-w)/2
+	switch style.Align {
+	case "left":
+		x = style.Padding
+	case "right":
+		x = size - style.Padding - w
+	}
+	y := style.Padding + (inner-h)/2
+	switch style.VAlign {
+	case "top":
+		y = style.Padding
+	case "bottom":
+		y = size - style.Padding - h
+	}
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// pillMask is an anti-aliased rounded bar, a circle when w == h
+func pillMask(w, h int) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	r := float64(h) / 2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			px, py := float64(x)+.5, float64(y)+.5
+			// distance from the line between the centers of the round ends
+			cx := math.Max(r, math.Min(float64(w)-r, px))
+			d := math.Hypot(px-cx, py-r)
+			a := math.Max(0, math.Min(1, r-d+.5))
+			mask.SetAlpha(x, y, color.Alpha{uint8(a * 255)})
+		}
+	}
+	return mask
+}
+
+// drawBadge draws a badge in its corner of img
+func drawBadge(img *image.RGBA, b *BadgeConfig, fonts []string) {
+	size := img.Bounds().Dx()
+	margin := max(2, size/24)
+
+	maxW := size - 2*margin
+
+	h := size / 6
+	var face *chainFace
+	textW := 0
+	if b.Text != "" {
+		h = size / 3
+		fs := float64(h) * .7
+		face = findFonts(fonts).face(fs)
+		textW = textWidth(face, b.Text)
+		// smaller text for a badge that would be wider than the key
+		for textW+h/2 > maxW && fs > 6 {
+			face.Close()
+			fs--
+			face = findFonts(fonts).face(fs)
+			textW = textWidth(face, b.Text)
+		}
+		defer face.Close()
+	}
+	w := max(h, textW+h/2)
+	w = min(w, maxW)
+
+	x, y := size-margin-w, margin
+	switch b.Corner {
+	case "top_left":
+		x = margin
+	case "bottom_right":
+		y = size - margin - h
+	case "bottom_left":
+		x, y = margin, size-margin-h
+	}
+
+	r := image.Rect(x, y, x+w, y+h)
+	draw.DrawMask(img, r, image.NewUniform(getColor(b.Color, "red")), image.Point{}, pillMask(w, h), image.Point{}, draw.Over)
+
+	if face != nil {
+		m := face.Metrics()
+		baseline := y + (h+m.Ascent.Ceil()-m.Descent.Ceil())/2
+		face.drawString(img, x+(w-textW)/2, baseline, b.Text, getColor(b.TextColor, "white"))
+	}
+}
+
+// drawKey composes a key from its layers: its color, then its image, text and badge
+func (ms *ModelSetup) drawKey(k KeyConfig) (*image.RGBA, error) {
+	size := ms.Conf.ButtonSize
+	img := newButtonImage(size, image.NewUniform(getColor(k.Color, "black")))
+
+	if k.Image != "" {
+		src, ok := assetImages[k.Image]
+		if !ok {
+			return nil, fmt.Errorf("unknown image [%s]", k.Image)
+		}
+		r := fitImage(src, size, k.ImageStyle)
+		draw.Draw(img, r, resizeImage(src, r.Dx(), r.Dy()), image.Point{}, draw.Over)
+	}
+
+	if k.Text != "" {
+		ms.DrawText(img, k.Text, k.TextColor, k.fonts(), k.TextStyle)
+	}
+
+	if k.Badge != nil {
+		drawBadge(img, k.Badge, k.fonts())
+	}
+	return img, nil
+}
@@ -0,0 +1,105 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+
+	"golang.org/x/image/colornames"
+)
+
+func TestFitImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+
+	test.That(t, fitImage(img, 100, nil), test.ShouldResemble, image.Rect(0, 0, 100, 100))
+	test.That(t, fitImage(img, 100, &ImageStyle{Fit: "contain"}), test.ShouldResemble, image.Rect(0, 25, 100, 75))
+	test.That(t, fitImage(img, 100, &ImageStyle{Fit: "contain", VAlign: "top"}), test.ShouldResemble, image.Rect(0, 0, 100, 50))
+	test.That(t, fitImage(img, 100, &ImageStyle{Fit: "cover"}), test.ShouldResemble, image.Rect(-50, 0, 150, 100))
+	test.That(t, fitImage(img, 100, &ImageStyle{Fit: "cover", Align: "left"}), test.ShouldResemble, image.Rect(0, 0, 200, 100))
+	test.That(t, fitImage(img, 100, &ImageStyle{Fit: "contain", Padding: 10, VAlign: "bottom"}), test.ShouldResemble, image.Rect(10, 50, 90, 90))
+
+	for _, is := range []ImageStyle{{Fit: "fill"}, {Align: "top"}, {VAlign: "left"}, {Padding: -1}} {
+		test.That(t, is.Validate(), test.ShouldNotBeNil)
+	}
+	bc := BadgeConfig{Corner: "top"}
+	test.That(t, bc.Validate(), test.ShouldNotBeNil)
+
+	// padding has to leave room for the image
+	test.That(t, (&ImageStyle{Padding: 35}).checkSize(72), test.ShouldBeNil)
+	test.That(t, (&ImageStyle{Padding: 36}).checkSize(72), test.ShouldNotBeNil)
+	conf := &Config{Keys: []KeyConfig{{Key: 0, Image: "x.jpg", ImageStyle: &ImageStyle{Padding: 40}, Component: "foo", Method: "do_command"}}}
+	_, _, err := conf.Validate("")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, conf.checkModel(ModelOriginal2), test.ShouldNotBeNil)
+	test.That(t, conf.checkModel(ModelPlus), test.ShouldBeNil)
+}
+
+func TestLongBadge(t *testing.T) {
+	size := 72
+	img := newButtonImage(size, image.Black)
+	// a badge the color of the key, so only its text shows
+	drawBadge(img, &BadgeConfig{Text: "1234567890", Color: "black"}, nil)
+
+	margin := max(2, size/24)
+	ink := inkBounds(img, 0)
+	test.That(t, ink.Empty(), test.ShouldBeFalse)
+	test.That(t, ink.Min.X, test.ShouldBeGreaterThanOrEqualTo, margin)
+	test.That(t, ink.Max.X, test.ShouldBeLessThanOrEqualTo, size-margin)
+}
+
+func pixel(img image.Image, x, y int) color.RGBA {
+	r, g, b, a := img.At(x, y).RGBA()
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
+
+func TestKeyLayers(t *testing.T) {
+	size := ModelPlus.Conf.ButtonSize
+	conf := &Config{
+		Keys: []KeyConfig{
+			{
+				Key: 0, Image: "x.jpg", Color: "green", Component: "foo", Method: "do_command",
+				ImageStyle: &ImageStyle{Fit: "contain", VAlign: "top", Padding: size / 4},
+				Text:       "hi", TextStyle: &TextStyle{VAlign: "bottom", Size: 12},
+				Badge: &BadgeConfig{Color: "yellow"},
+			},
+			{Key: 1, Text: "a", Color: "black", Component: "foo", Method: "do_command"},
+		},
+	}
+	sdc, fd, _ := newTestDeck(t, conf)
+
+	// the image is in the middle of the key's color, with the badge top right
+	key := fd.Key(0)
+	test.That(t, pixel(key, size/2, size/2), test.ShouldNotResemble, colornames.Green)
+	test.That(t, pixel(key, 1, size/2), test.ShouldResemble, colornames.Green)
+	test.That(t, pixel(key, size/2, size/4-1), test.ShouldResemble, colornames.Green)
+	m := max(2, size/24)
+	test.That(t, pixel(key, size-m-size/12, m+size/12), test.ShouldResemble, colornames.Yellow)
+
+	// badges can be set and removed
+	badge := func(b interface{}) {
+		_, err := sdc.DoCommand(context.Background(), map[string]interface{}{
+			"update_display": map[string]interface{}{"keys": map[string]interface{}{"1": map[string]interface{}{"badge": b}}},
+		})
+		test.That(t, err, test.ShouldBeNil)
+	}
+	ink := func() image.Rectangle {
+		return inkBounds(fd.Key(1).(*image.RGBA).SubImage(image.Rect(size/2, 0, size, size/3)), 0)
+	}
+	test.That(t, ink().Empty(), test.ShouldBeTrue)
+	badge("3")
+	test.That(t, pixel(fd.Key(1), size-m-3, m+size/6), test.ShouldResemble, colornames.Red)
+	badge(map[string]interface{}{"color": "blue", "corner": "bottom_left"})
+	test.That(t, ink().Empty(), test.ShouldBeTrue)
+	badge(nil)
+	sdc.configLock.Lock()
+	test.That(t, sdc.keys[1].Badge, test.ShouldBeNil)
+	sdc.configLock.Unlock()
+
+	_, err := sdc.DoCommand(context.Background(), map[string]interface{}{
+		"update_display": map[string]interface{}{"keys": map[string]interface{}{"1": map[string]interface{}{"badge": map[string]interface{}{"corner": "x"}}}},
+	})
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -2,9 +2,11 @@ package viamstreamdeck
 
 import (
 	"fmt"
+	"maps"
 	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"go.viam.com/rdk/logging"
 )
@@ -13,37 +15,162 @@ type KeyConfig struct {
 	Key int
 
 	Text      string
-	TextColor string  `json:"text_color"`
-	TextFont  *string `json:"text_font,omitempty"`
+	TextColor string      `json:"text_color"`
+	TextFont  interface{} `json:"text_font,omitempty"` // a font, or a list to try in order for each character
+	TextStyle *TextStyle  `json:"text_style,omitempty"`
 
-	Color string
-	Image string
+	Color      string
+	Image      string
+	ImageStyle *ImageStyle  `json:"image_style,omitempty"`
+	Badge      *BadgeConfig `json:"badge,omitempty"`
+	Theme      string       `json:"theme,omitempty"` // instead of the page's or deck's theme
 
 	Component string
 	Method    string
 	Args      []interface{}
+
+	// steps to run in order instead of a single method
+	Macro []MacroStep `json:"macro,omitempty"`
+
+	// the key has to be pressed again within confirm_ms (default 3000) to run
+	Confirm   bool `json:"confirm,omitempty"`
+	ConfirmMs int  `json:"confirm_ms,omitempty"`
+
+	// the key shows one of States, pressing it runs that state's action and moves to the next.
+	// With Follow, the key is in the state at that switch's position instead.
+	States []KeyState `json:"states,omitempty"`
+	Follow string     `json:"follow,omitempty"`
+
+	// the first of Styles that matches State changes how the key looks
+	State  *StateConfig `json:"state,omitempty"`
+	Styles []StyleRule  `json:"styles,omitempty"`
+
+	// actions are cancelled after timeout_ms, no limit by default.
+	// Policy is for presses while one is still running: queue (default), drop or cancel_previous.
+	TimeoutMs int    `json:"timeout_ms,omitempty"`
+	Policy    string `json:"policy,omitempty"`
+
+	// momentary actions, e.g. start a motor on press and stop it on release
+	OnPress   *ActionConfig `json:"on_press,omitempty"`
+	OnRelease *ActionConfig `json:"on_release,omitempty"`
+
+	// optional extra actions for the same key
+	LongPress     *ActionConfig `json:"long_press,omitempty"`
+	LongPressMs   int           `json:"long_press_ms,omitempty"` // how long to hold, defaults to 500
+	DoublePress   *ActionConfig `json:"double_press,omitempty"`
+	DoublePressMs int           `json:"double_press_ms,omitempty"` // how long to wait for the second press, defaults to 300
+
+	// Sensor is any resource with readings, they fill in Template to make the key's text
+	Sensor      string  `json:"sensor,omitempty"`
+	Template    string  `json:"template,omitempty"`
+	RefreshSecs float64 `json:"refresh_secs,omitempty"` // how often to re-read the sensor, defaults to 1
+}
+
+// fonts returns the names of the fonts to draw the key's text with, in order
+func (kc *KeyConfig) fonts() []string {
+	names, _ := fontNames(kc.TextFont)
+	return names
+}
+
+// checkSize makes sure the key can be drawn on a size x size key
+func (kc *KeyConfig) checkSize(size int) error {
+	if kc.ImageStyle != nil {
+		if err := kc.ImageStyle.checkSize(size); err != nil {
+			return fmt.Errorf("key %d: %w", kc.Key, err)
+		}
+	}
+	return nil
 }
 
 func (kc *KeyConfig) Validate() error {
-	if kc.Component == "" {
+	if kc.Sensor != "" {
+		if kc.Template == "" {
+			return fmt.Errorf("need a template for sensor %s", kc.Sensor)
+		}
+		if _, err := parseKeyTemplate(kc.Template); err != nil {
+			return fmt.Errorf("bad template for key %d: %w", kc.Key, err)
+		}
+		if kc.RefreshSecs < 0 {
+			return fmt.Errorf("refresh_secs can't be negative")
+		}
+	}
+
+	// a key showing a sensor or state, or with momentary actions, doesn't need a normal action
+	if kc.Component == "" && kc.Sensor == "" && kc.State == nil && kc.OnPress == nil && kc.OnRelease == nil && len(kc.Macro) == 0 && len(kc.States) == 0 {
 		return fmt.Errorf("need a component")
 	}
-	if kc.Method == "" {
-		return fmt.Errorf("need a method")
+	if kc.Component != "" && len(kc.Macro) > 0 {
+		return fmt.Errorf("key %d: can't have both a component and a macro", kc.Key)
+	}
+	for i, step := range kc.Macro {
+		if err := step.Validate(); err != nil {
+			return fmt.Errorf("key %d macro step %d: %w", kc.Key, i, err)
+		}
+	}
+	if len(kc.States) > 0 {
+		if err := kc.validateStates(); err != nil {
+			return err
+		}
+	} else if kc.Follow != "" {
+		return fmt.Errorf("key %d: follow needs states", kc.Key)
+	} else if kc.Component != "" {
+		if err := kc.action().Validate(); err != nil {
+			return fmt.Errorf("key %d: %w", kc.Key, err)
+		}
 	}
 
-	// Validate font exists (if specified)
-	if kc.TextFont != nil {
-		if _, ok := assetFonts[*kc.TextFont]; !ok {
-			availableFonts := []string{}
-			for fontName := range assetFonts {
-				availableFonts = append(availableFonts, fontName)
-			}
-			sort.Strings(availableFonts)
-			return fmt.Errorf("unknown font %s. Available fonts: %s", *kc.TextFont, strings.Join(availableFonts, ", "))
+	extra := kc.extraActions()
+	for _, name := range slices.Sorted(maps.Keys(extra)) {
+		if err := extra[name].Validate(); err != nil {
+			return fmt.Errorf("key %d %s: %w", kc.Key, name, err)
+		}
+	}
+	// confirm only guards the normal action, so it can't be mixed with actions that would run unconfirmed
+	if kc.Confirm && len(extra) > 0 {
+		return fmt.Errorf("key %d: confirm can't be used with %s", kc.Key, strings.Join(slices.Sorted(maps.Keys(extra)), ", "))
+	}
+	if kc.LongPressMs < 0 || kc.DoublePressMs < 0 || kc.ConfirmMs < 0 || kc.TimeoutMs < 0 {
+		return fmt.Errorf("key %d: long_press_ms, double_press_ms, confirm_ms and timeout_ms can't be negative", kc.Key)
+	}
+	if err := checkPolicy(kc.Policy); err != nil {
+		return fmt.Errorf("key %d: %w", kc.Key, err)
+	}
+
+	if len(kc.Styles) > 0 && kc.State == nil {
+		return fmt.Errorf("key %d: styles need a state", kc.Key)
+	}
+	if kc.State != nil {
+		if err := kc.State.Validate(); err != nil {
+			return fmt.Errorf("key %d state: %w", kc.Key, err)
+		}
+	}
+	for i, r := range kc.Styles {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("key %d style %d: %w", kc.Key, i, err)
 		}
 	}
 
+	if kc.TextStyle != nil {
+		if err := kc.TextStyle.Validate(); err != nil {
+			return fmt.Errorf("key %d text_style: %w", kc.Key, err)
+		}
+	}
+	if kc.ImageStyle != nil {
+		if err := kc.ImageStyle.Validate(); err != nil {
+			return fmt.Errorf("key %d image_style: %w", kc.Key, err)
+		}
+	}
+	if kc.Badge != nil {
+		if err := kc.Badge.Validate(); err != nil {
+			return fmt.Errorf("key %d badge: %w", kc.Key, err)
+		}
+	}
+
+	// Validate fonts exist (if specified)
+	if err := checkFonts(kc.TextFont); err != nil {
+		return fmt.Errorf("key %d: %w", kc.Key, err)
+	}
+
 	// Validate image exists (if specified)
 	if kc.Image != "" {
 		if _, ok := assetImages[kc.Image]; !ok {
@@ -59,38 +186,290 @@ func (kc *KeyConfig) Validate() error {
 	return nil
 }
 
-func (kc *KeyConfig) snakeMethod() string {
-	return snakeToCamel(kc.Method)
+// addDeps adds the resources the key uses to deps
+func (kc *KeyConfig) addDeps(deps []string) []string {
+	names := []string{kc.Component, kc.Sensor, kc.Follow}
+	for _, ks := range kc.States {
+		names = append(names, ks.Component)
+	}
+	if kc.State != nil {
+		names = append(names, kc.State.Component)
+	}
+	extra := kc.extraActions()
+	for _, name := range slices.Sorted(maps.Keys(extra)) {
+		names = append(names, extra[name].Component)
+	}
+	for _, step := range kc.Macro {
+		names = append(names, step.Component)
+		if step.If != nil {
+			names = append(names, step.If.Sensor)
+		}
+	}
+
+	for _, n := range names {
+		if n != "" && !slices.Contains(deps, n) {
+			deps = append(deps, n)
+		}
+	}
+	return deps
+}
+
+// extraActions are the key's actions besides the normal one, by config name
+func (kc *KeyConfig) extraActions() map[string]*ActionConfig {
+	res := map[string]*ActionConfig{}
+	for name, a := range map[string]*ActionConfig{
+		"on_press":     kc.OnPress,
+		"on_release":   kc.OnRelease,
+		"long_press":   kc.LongPress,
+		"double_press": kc.DoublePress,
+	} {
+		if a != nil {
+			res[name] = a
+		}
+	}
+	return res
+}
+
+// action is what a normal press does
+func (kc *KeyConfig) action() *ActionConfig {
+	return &ActionConfig{Component: kc.Component, Method: kc.Method, Args: kc.Args}
+}
+
+func (kc *KeyConfig) longPressDuration() time.Duration {
+	if kc.LongPressMs <= 0 {
+		return 500 * time.Millisecond
+	}
+	return time.Duration(kc.LongPressMs) * time.Millisecond
+}
+
+func (kc *KeyConfig) doublePressDuration() time.Duration {
+	if kc.DoublePressMs <= 0 {
+		return 300 * time.Millisecond
+	}
+	return time.Duration(kc.DoublePressMs) * time.Millisecond
+}
+
+// ActionConfig is a method to call on a component
+type ActionConfig struct {
+	Component string        `json:"component"`
+	Method    string        `json:"method"`
+	Args      []interface{} `json:"args,omitempty"`
+}
+
+// Validate makes sure Method is one we can call and Args fit it
+func (ac *ActionConfig) Validate() error {
+	if ac.Component == "" {
+		return fmt.Errorf("need a component")
+	}
+	if ac.Method == "" {
+		return fmt.Errorf("need a method")
+	}
+	a, err := findKeyAction(ac.Method)
+	if err != nil {
+		return err
+	}
+	if err := a.checkArgs(ac.Args); err != nil {
+		return fmt.Errorf("%s: %w", ac.Method, err)
+	}
+	return nil
+}
+
+func (kc *KeyConfig) isMethod(method string) bool {
+	return normalizeMethod(kc.Method) == normalizeMethod(method)
 }
 
 type DialConfig struct {
 	Dial      int
 	Component string
 	Command   string
+
+	Press *ActionConfig `json:"press,omitempty"` // when the dial is pushed in
+
+	// the value the dial turns through, each tick moves it by step.
+	// It defaults to 0 to 100 starting at 50, or every position of a SetPosition switch starting where it is.
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+	Step    float64  `json:"step,omitempty"`
+	Initial *float64 `json:"initial,omitempty"`
+	Wrap    bool     `json:"wrap,omitempty"` // go round from max to min instead of stopping
+
+	// what is sent is value * scale + offset, scale defaults to 1
+	Scale  float64 `json:"scale,omitempty"`
+	Offset float64 `json:"offset,omitempty"`
+
+	// for DoCommand, what to send instead of {"DoCommand": value}.
+	// Strings in it are templates with .value, .delta, .ticks and .dial.
+	Payload map[string]interface{} `json:"payload,omitempty"`
+
+	// shown on the touch strip above the dial, label defaults to the component
+	Label    string `json:"label,omitempty"`
+	Sensor   string `json:"sensor,omitempty"` // with Template, the value shown instead of the dial position
+	Template string `json:"template,omitempty"`
+	Bar      bool   `json:"bar,omitempty"` // draw the dial position as a bar
+
+	// like a key's, for turns and presses while the dial's last action is still running
+	TimeoutMs int    `json:"timeout_ms,omitempty"`
+	Policy    string `json:"policy,omitempty"`
 }
 
 func (dc *DialConfig) Validate() error {
-	if dc.Component == "" {
+	if dc.Component == "" && dc.Press == nil {
 		return fmt.Errorf("need a component")
 	}
-	if dc.Command == "" {
+	if dc.Component != "" && dc.Command == "" {
 		return fmt.Errorf("need a command")
 	}
+	if dc.Press != nil {
+		if err := dc.Press.Validate(); err != nil {
+			return fmt.Errorf("dial %d press: %w", dc.Dial, err)
+		}
+	}
+	if dc.Step < 0 {
+		return fmt.Errorf("dial %d: step can't be negative", dc.Dial)
+	}
+	if dc.TimeoutMs < 0 {
+		return fmt.Errorf("dial %d: timeout_ms can't be negative", dc.Dial)
+	}
+	if err := checkPolicy(dc.Policy); err != nil {
+		return fmt.Errorf("dial %d: %w", dc.Dial, err)
+	}
+	if dc.Min != nil && dc.Max != nil && *dc.Min >= *dc.Max {
+		return fmt.Errorf("dial %d: min %v has to be less than max %v", dc.Dial, *dc.Min, *dc.Max)
+	}
+	if dc.Payload != nil {
+		if _, err := dc.payload(dialTurn{}); err != nil {
+			return fmt.Errorf("bad payload for dial %d: %w", dc.Dial, err)
+		}
+	}
+	if dc.Sensor != "" {
+		if dc.Template == "" {
+			return fmt.Errorf("dial %d: need a template for sensor %s", dc.Dial, dc.Sensor)
+		}
+		if _, err := parseKeyTemplate(dc.Template); err != nil {
+			return fmt.Errorf("bad template for dial %d: %w", dc.Dial, err)
+		}
+	}
 	return nil
 }
 
+func (dc *DialConfig) label() string {
+	if dc.Label != "" {
+		return dc.Label
+	}
+	return dc.Component
+}
+
+// TouchConfig is for the touch strip of the plus, it has one segment above each dial
+type TouchConfig struct {
+	Segments []TouchSegmentConfig `json:"segments,omitempty"`
+
+	SwipeLeft  *ActionConfig `json:"swipe_left,omitempty"`
+	SwipeRight *ActionConfig `json:"swipe_right,omitempty"`
+	// SwipePages goes to the next page on a swipe left and the previous one on a swipe right,
+	// unless swipe_left or swipe_right are set
+	SwipePages bool `json:"swipe_pages,omitempty"`
+
+	// like a key's, for taps and swipes while the last one's action is still running
+	TimeoutMs int    `json:"timeout_ms,omitempty"`
+	Policy    string `json:"policy,omitempty"`
+}
+
+type TouchSegmentConfig struct {
+	Segment   int
+	Tap       *ActionConfig `json:"tap,omitempty"`
+	LongPress *ActionConfig `json:"long_press,omitempty"`
+}
+
+func (tc *TouchConfig) Validate() error {
+	if tc.TimeoutMs < 0 {
+		return fmt.Errorf("timeout_ms can't be negative")
+	}
+	if err := checkPolicy(tc.Policy); err != nil {
+		return err
+	}
+	for _, seg := range tc.Segments {
+		if seg.Segment < 0 {
+			return fmt.Errorf("invalid segment %d", seg.Segment)
+		}
+		for name, a := range map[string]*ActionConfig{"tap": seg.Tap, "long_press": seg.LongPress} {
+			if a == nil {
+				continue
+			}
+			if err := a.Validate(); err != nil {
+				return fmt.Errorf("segment %d %s: %w", seg.Segment, name, err)
+			}
+		}
+	}
+	for name, a := range map[string]*ActionConfig{"swipe_left": tc.SwipeLeft, "swipe_right": tc.SwipeRight} {
+		if a == nil {
+			continue
+		}
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// actions are all the actions in the touch config
+func (tc *TouchConfig) actions() []*ActionConfig {
+	res := []*ActionConfig{}
+	for _, seg := range tc.Segments {
+		res = append(res, seg.Tap, seg.LongPress)
+	}
+	res = append(res, tc.SwipeLeft, tc.SwipeRight)
+	return slices.DeleteFunc(res, func(a *ActionConfig) bool { return a == nil })
+}
+
 type AssetsConfig struct {
 	Fonts  []string `json:"fonts,omitempty"`
 	Images []string `json:"images,omitempty"`
 }
 
+// VirtualConfig is only used by the streamdeck-virtual model
+type VirtualConfig struct {
+	Port   int    `json:"port,omitempty"`   // defaults to 8765
+	Layout string `json:"layout,omitempty"` // which deck to mimic, defaults to plus
+}
+
+func (vc *VirtualConfig) port() int {
+	if vc == nil || vc.Port <= 0 {
+		return 8765
+	}
+	return vc.Port
+}
+
+func (vc *VirtualConfig) layout() string {
+	if vc == nil || vc.Layout == "" {
+		return "plus"
+	}
+	return vc.Layout
+}
+
+func (vc *VirtualConfig) Validate() error {
+	if vc.Port < 0 || vc.Port > 65535 {
+		return fmt.Errorf("invalid port %d", vc.Port)
+	}
+	_, err := ModelVirtual.withLayout(vc.layout())
+	return err
+}
+
 type Config struct {
+	Serial      string `json:"serial,omitempty"` // which deck to use when several are attached
 	Brightness  int
 	Keys        []KeyConfig            `json:"keys,omitempty"`
 	Pages       map[string][]KeyConfig `json:"pages,omitempty"`
 	InitialPage string                 `json:"initial_page,omitempty"`
 	Dials       []DialConfig
-	Assets      *AssetsConfig          `json:"assets,omitempty"`
+	Touch       *TouchConfig    `json:"touch,omitempty"`
+	Feedback    *FeedbackConfig `json:"feedback,omitempty"`
+
+	Themes     map[string]ThemeConfig `json:"themes,omitempty"`
+	Theme      string                 `json:"theme,omitempty"`       // the deck's theme until set_theme changes it
+	PageThemes map[string]string      `json:"page_themes,omitempty"` // by page, instead of the deck's theme
+
+	Assets  *AssetsConfig  `json:"assets,omitempty"`
+	Virtual *VirtualConfig `json:"virtual,omitempty"`
 }
 
 type UpdateDisplayCommand struct {
@@ -99,6 +478,23 @@ type UpdateDisplayCommand struct {
 	Dials      map[string]map[string]interface{} `mapstructure:"dials"`
 }
 
+// checkModel makes sure every key can be drawn on the model's keys, which Validate doesn't know
+func (c *Config) checkModel(ms *ModelSetup) error {
+	for _, k := range c.Keys {
+		if err := k.checkSize(ms.Conf.ButtonSize); err != nil {
+			return err
+		}
+	}
+	for page, keys := range c.Pages {
+		for _, k := range keys {
+			if err := k.checkSize(ms.Conf.ButtonSize); err != nil {
+				return fmt.Errorf("page %s: %w", page, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Config) Validate(p string) ([]string, []string, error) {
 	// Create logger for validation
 	logger := logging.NewLogger("viamstreamdeck-config")
@@ -145,9 +541,7 @@ func (c *Config) Validate(p string) ([]string, []string, error) {
 			return nil, nil, err
 		}
 
-		if !slices.Contains(ret, k.Component) {
-			ret = append(ret, k.Component)
-		}
+		ret = k.addDeps(ret)
 	}
 
 	for pageName, keys := range c.Pages {
@@ -160,9 +554,7 @@ func (c *Config) Validate(p string) ([]string, []string, error) {
 				return nil, nil, fmt.Errorf("page %s: %w", pageName, err)
 			}
 
-			if !slices.Contains(ret, k.Component) {
-				ret = append(ret, k.Component)
-			}
+			ret = k.addDeps(ret)
 		}
 	}
 
@@ -176,6 +568,25 @@ func (c *Config) Validate(p string) ([]string, []string, error) {
 		}
 	}
 
+	err := c.validateThemes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.Virtual != nil {
+		err := c.Virtual.Validate()
+		if err != nil {
+			return nil, nil, fmt.Errorf("virtual: %w", err)
+		}
+	}
+
+	if c.Feedback != nil {
+		err := c.Feedback.Validate()
+		if err != nil {
+			return nil, nil, fmt.Errorf("feedback: %w", err)
+		}
+	}
+
 	// Validate dials
 	for _, d := range c.Dials {
 		err := d.Validate()
@@ -183,14 +594,39 @@ func (c *Config) Validate(p string) ([]string, []string, error) {
 			return nil, nil, err
 		}
 
-		if !slices.Contains(ret, d.Component) {
+		if d.Component != "" && !slices.Contains(ret, d.Component) {
 			ret = append(ret, d.Component)
 		}
+		if d.Press != nil && !slices.Contains(ret, d.Press.Component) {
+			ret = append(ret, d.Press.Component)
+		}
+		if d.Sensor != "" && !slices.Contains(ret, d.Sensor) {
+			ret = append(ret, d.Sensor)
+		}
+	}
+
+	if c.Touch != nil {
+		err := c.Touch.Validate()
+		if err != nil {
+			return nil, nil, fmt.Errorf("touch: %w", err)
+		}
+		for _, a := range c.Touch.actions() {
+			if !slices.Contains(ret, a.Component) {
+				ret = append(ret, a.Component)
+			}
+		}
 	}
 
 	return nil, ret, nil
 }
 
+func (c *Config) serial() string {
+	if c == nil {
+		return ""
+	}
+	return c.Serial
+}
+
 // GetPageNames returns a sorted list of page names
 func (c *Config) GetPageNames() []string {
 	names := make([]string, 0, len(c.Pages))
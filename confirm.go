@@ -0,0 +1,84 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"time"
+)
+
+func (kc *KeyConfig) confirmDuration() time.Duration {
+	if kc.ConfirmMs <= 0 {
+		return 3 * time.Second
+	}
+	return time.Duration(kc.ConfirmMs) * time.Millisecond
+}
+
+// confirming is whether a key has been pressed once and is waiting for the press that runs it
+func (sdc *streamdeckComponent) confirming(which int) bool {
+	sdc.pressLock.Lock()
+	defer sdc.pressLock.Unlock()
+	kp, ok := sdc.presses[which]
+	return ok && kp.confirmTimer != nil
+}
+
+// confirmPress returns true if this is the second press of a key that needs confirming,
+// otherwise it shows the key as waiting to be confirmed until it times out
+func (sdc *streamdeckComponent) confirmPress(ctx context.Context, k KeyConfig) bool {
+	sdc.pressLock.Lock()
+	kp := sdc.keyPressState(k.Key)
+
+	if kp.confirmTimer != nil {
+		kp.confirmTimer.Stop()
+		kp.confirmTimer = nil
+		sdc.pressLock.Unlock()
+		sdc.redrawKey(ctx, k.Key)
+		return true
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(k.confirmDuration(), func() {
+		sdc.pressLock.Lock()
+		if kp.confirmTimer != t {
+			sdc.pressLock.Unlock()
+			return
+		}
+		kp.confirmTimer = nil
+		sdc.pressLock.Unlock()
+		sdc.redrawKey(context.Background(), k.Key)
+	})
+	kp.confirmTimer = t
+	sdc.pressLock.Unlock()
+
+	sdc.redrawKey(ctx, k.Key)
+	return false
+}
+
+func (sdc *streamdeckComponent) drawConfirm(k KeyConfig) error {
+	return sdc.sd.WriteText(k.Key, sdc.ms.SimpleTextButtonFonts("Confirm?", "red", "white", k.fonts()))
+}
+
+// cancelConfirms stops waiting for confirmations, the keys go back to normal on their next redraw
+func (sdc *streamdeckComponent) cancelConfirms() {
+	sdc.pressLock.Lock()
+	defer sdc.pressLock.Unlock()
+	for _, kp := range sdc.presses {
+		if kp.confirmTimer != nil {
+			kp.confirmTimer.Stop()
+			kp.confirmTimer = nil
+		}
+	}
+}
+
+// redrawKey draws a key as it is now configured
+func (sdc *streamdeckComponent) redrawKey(ctx context.Context, which int) {
+	sdc.configLock.Lock()
+	defer sdc.configLock.Unlock()
+
+	k, ok := sdc.keys[which]
+	if !ok {
+		return
+	}
+	err := sdc.updateKey(ctx, k)
+	if err != nil {
+		sdc.logger.Warnf("can't redraw key %d: %v", which, err)
+	}
+}
@@ -0,0 +1,36 @@
+package viamstreamdeck
+
+import (
+	"errors"
+	"image"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+// Deck is the subset of a stream deck device that the components drive.
+// *streamdeck.StreamDeck satisfies it for real hardware.
+type Deck interface {
+	WriteText(btnIndex int, textBtn streamdeck.TextButton) error
+	WriteTextOnImage(btnIndex int, imgIn image.Image, lines []streamdeck.TextLine) error
+	FillImage(btnIndex int, img image.Image) error
+	ClearBtn(btnIndex int) error
+	ClearAllBtns() error
+	// FillTouchStrip draws img on the touch screen with its top left corner at at,
+	// decks without a touch screen return errNoTouchStrip
+	FillTouchStrip(at image.Point, img image.Image) error
+	SetBrightness(b uint16) error
+	SetBtnEventCb(ev streamdeck.BtnEvent)
+	Close() error
+}
+
+var errNoTouchStrip = errors.New("streamdeck has no touch strip")
+
+// openHardwareDeck opens the usb device described by ms
+func openHardwareDeck(ms *ModelSetup, conf *Config) (Deck, error) {
+	c := ms.Conf
+	sd, err := streamdeck.NewStreamDeckWithConfig(&c, conf.serial())
+	if err != nil {
+		return nil, err
+	}
+	return &libraryDeck{sd, ms.productIDs(), sd.Serial()}, nil
+}
@@ -0,0 +1,199 @@
+package viamstreamdeck
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+// dialStartPos is where every deck starts counting a dial's position when it is opened
+const dialStartPos = streamdeck.DialMax / 2
+
+// dialState is where a dial has been turned to
+type dialState struct {
+	raw   int     // last position from the device, to tell how far it turned
+	value float64 // between min and max, before scale and offset
+}
+
+func (dc *DialConfig) step() float64 {
+	if dc.Step <= 0 {
+		return 1
+	}
+	return dc.Step
+}
+
+func (dc *DialConfig) scale() float64 {
+	if dc.Scale == 0 {
+		return 1
+	}
+	return dc.Scale
+}
+
+// output is what is sent for value
+func (dc *DialConfig) output(value float64) float64 {
+	return value*dc.scale() + dc.Offset
+}
+
+// turn moves value by ticks steps, keeping it between lo and hi
+func (dc *DialConfig) turn(value float64, ticks int, lo, hi float64) float64 {
+	step := dc.step()
+	v := value + float64(ticks)*step
+
+	if dc.Wrap {
+		// past hi is one step to lo
+		span := hi - lo + step
+		v = lo + math.Mod(math.Mod(v-lo, span)+span, span)
+	}
+	v = min(hi, max(lo, v))
+
+	// stay on a step from lo so repeated turns don't drift
+	return min(hi, lo+math.Round((v-lo)/step)*step)
+}
+
+// dialPosition is where the switch a SetPosition dial controls was last read at
+func (sdc *streamdeckComponent) dialPosition(dc DialConfig) (uint32, bool) {
+	if dc.Command != "SetPosition" {
+		return 0, false
+	}
+	p, err := sdc.lastPosition(dc.Component)
+	return p, err == nil
+}
+
+// dialPositions are the last read positions of the switch a SetPosition dial controls
+func (sdc *streamdeckComponent) dialPositions(dc DialConfig) (switchPositions, bool) {
+	if dc.Command != "SetPosition" {
+		return switchPositions{}, false
+	}
+	sp, err := sdc.lastPositions(dc.Component)
+	return sp, err == nil
+}
+
+// dialLimits returns the range of a dial's value
+func (sdc *streamdeckComponent) dialLimits(dc DialConfig) (float64, float64) {
+	lo, hi := 0.0, float64(streamdeck.DialMax)
+	if sp, ok := sdc.dialPositions(dc); ok && sp.n > 0 {
+		hi = float64(sp.n - 1)
+	}
+	if dc.Min != nil {
+		lo = *dc.Min
+	}
+	if dc.Max != nil {
+		hi = *dc.Max
+	}
+	return lo, max(lo, hi)
+}
+
+// dialState returns what a dial is at, starting it at its initial value if it hasn't been used.
+// configLock must be held.
+func (sdc *streamdeckComponent) dialState(dc DialConfig) *dialState {
+	ds, ok := sdc.dials[dc.Dial]
+	if ok {
+		return ds
+	}
+
+	lo, hi := sdc.dialLimits(dc)
+	ds = &dialState{raw: dialStartPos, value: (lo + hi) / 2}
+	if dc.Initial != nil {
+		ds.value = *dc.Initial
+	} else if p, ok := sdc.dialPosition(dc); ok {
+		ds.value = float64(p)
+	}
+	ds.value = min(hi, max(lo, ds.value))
+
+	sdc.dials[dc.Dial] = ds
+	return ds
+}
+
+// dialTurn is what one turn of a dial did, in what is sent rather than the dial's own value
+type dialTurn struct {
+	dial  int
+	value float64
+	delta float64
+	ticks int
+}
+
+// turnDial moves a dial's value by how far the device says it turned.
+// configLock must be held.
+func (sdc *streamdeckComponent) turnDial(dc DialConfig, raw int) dialTurn {
+	ds := sdc.dialState(dc)
+	lo, hi := sdc.dialLimits(dc)
+
+	before := dc.output(ds.value)
+	ticks := raw - ds.raw
+	ds.value = dc.turn(ds.value, ticks, lo, hi)
+	ds.raw = raw
+
+	value := dc.output(ds.value)
+	return dialTurn{dial: dc.Dial, value: value, delta: value - before, ticks: ticks}
+}
+
+// payload is what a DoCommand dial sends for a turn
+func (dc *DialConfig) payload(t dialTurn) (map[string]interface{}, error) {
+	if dc.Payload == nil {
+		return map[string]interface{}{dc.Command: t.value}, nil
+	}
+
+	data := map[string]interface{}{"value": t.value, "delta": t.delta, "ticks": t.ticks, "dial": t.dial}
+	res, err := fillPayload(dc.Payload, data)
+	if err != nil {
+		return nil, err
+	}
+	return res.(map[string]interface{}), nil
+}
+
+// fillPayload runs every string in v as a template, strings that come out as a number are sent as one
+func fillPayload(v interface{}, data map[string]interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case string:
+		if !strings.Contains(x, "{{") {
+			return x, nil
+		}
+		t, err := parseKeyTemplate(x)
+		if err != nil {
+			return nil, err
+		}
+		buf := bytes.Buffer{}
+		err = t.Execute(&buf, data)
+		if err != nil {
+			return nil, err
+		}
+		if f, err := strconv.ParseFloat(buf.String(), 64); err == nil {
+			return f, nil
+		}
+		return buf.String(), nil
+	case map[string]interface{}:
+		res := map[string]interface{}{}
+		for k, e := range x {
+			f, err := fillPayload(e, data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			res[k] = f
+		}
+		return res, nil
+	case []interface{}:
+		res := []interface{}{}
+		for i, e := range x {
+			f, err := fillPayload(e, data)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", i, err)
+			}
+			res = append(res, f)
+		}
+		return res, nil
+	}
+	return v, nil
+}
+
+// resetDials is for when the deck is reopened and starts counting dial positions again
+func (sdc *streamdeckComponent) resetDials() {
+	sdc.configLock.Lock()
+	defer sdc.configLock.Unlock()
+	for _, ds := range sdc.dials {
+		ds.raw = dialStartPos
+	}
+}
@@ -0,0 +1,114 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/rdk/components/generic"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+)
+
+func TestDialTurn(t *testing.T) {
+	dc := DialConfig{Step: 0.1}
+	test.That(t, dc.turn(0, 3, -1, 1), test.ShouldAlmostEqual, 0.3)
+	test.That(t, dc.turn(0.9, 5, -1, 1), test.ShouldEqual, 1)
+	test.That(t, dc.turn(-0.9, -5, -1, 1), test.ShouldEqual, -1)
+
+	dc = DialConfig{Wrap: true}
+	test.That(t, dc.turn(2, 1, 0, 2), test.ShouldEqual, 0)
+	test.That(t, dc.turn(0, -1, 0, 2), test.ShouldEqual, 2)
+	test.That(t, dc.turn(1, 7, 0, 2), test.ShouldEqual, 2)
+
+	dc = DialConfig{Scale: 2, Offset: -90}
+	test.That(t, dc.output(90), test.ShouldEqual, 90)
+
+	lo, hi := 1.0, 0.0
+	dc = DialConfig{Dial: 0, Component: "m", Command: "DoCommand", Min: &lo, Max: &hi}
+	test.That(t, dc.Validate(), test.ShouldNotBeNil)
+}
+
+func TestDialRanges(t *testing.T) {
+	thing := &testThing{name: generic.Named("foo")}
+
+	position := uint32(2)
+	sw := inject.NewSwitch("sw")
+	sw.GetPositionFunc = func(ctx context.Context, extra map[string]interface{}) (uint32, error) {
+		return position, nil
+	}
+	sw.SetPositionFunc = func(ctx context.Context, p uint32, extra map[string]interface{}) error {
+		position = p
+		return nil
+	}
+	sw.GetNumberOfPositionsFunc = func(ctx context.Context, extra map[string]interface{}) (uint32, []string, error) {
+		return 3, []string{"low", "mid", "high"}, nil
+	}
+
+	lo, hi := -1.0, 1.0
+	conf := &Config{
+		Keys: []KeyConfig{{Key: 0, Text: "a", Component: "foo", Method: "do_command"}},
+		Dials: []DialConfig{
+			{Dial: 0, Component: "foo", Command: "DoCommand", Min: &lo, Max: &hi, Step: 0.25},
+			{Dial: 1, Component: "sw", Command: "SetPosition", Wrap: true},
+		},
+	}
+
+	fd := NewFakeDeck(streamdeck.Plus)
+	deps := resource.Dependencies{thing.name: thing, sw.Name(): sw}
+	r, err := NewStreamDeck(context.Background(), generic.Named("deck"), deps, fd.Model(), conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer r.Close(context.Background())
+	sdc := r.(*streamdeckComponent)
+
+	fd.TurnDial(0, 2)
+	fd.TurnDial(0, 10)
+	fd.TurnDial(0, -1)
+	waitForActions(sdc)
+	test.That(t, thing.commands(), test.ShouldResemble, []map[string]interface{}{
+		{"DoCommand": 0.5}, {"DoCommand": 1.0}, {"DoCommand": 0.75},
+	})
+
+	// starts where the switch is and goes round its positions
+	fd.TurnDial(1, 1)
+	waitForActions(sdc)
+	test.That(t, position, test.ShouldEqual, 0)
+	fd.TurnDial(1, -1)
+	waitForActions(sdc)
+	test.That(t, position, test.ShouldEqual, 2)
+	fd.TurnDial(1, -4)
+	waitForActions(sdc)
+	test.That(t, position, test.ShouldEqual, 1)
+}
+
+func TestDialPayload(t *testing.T) {
+	dc := DialConfig{Dial: 2, Component: "fan", Command: "DoCommand", Payload: map[string]interface{}{
+		"set_speed": "{{.value}}",
+		"delta":     "{{.delta}}",
+		"name":      "dial {{.dial}}",
+		"fixed":     "x",
+		"list":      []interface{}{"{{.ticks}}", true},
+	}}
+	test.That(t, dc.Validate(), test.ShouldBeNil)
+
+	cmd, err := dc.payload(dialTurn{dial: 2, value: 40, delta: -5, ticks: -1})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cmd, test.ShouldResemble, map[string]interface{}{
+		"set_speed": 40.0,
+		"delta":     -5.0,
+		"name":      "dial 2",
+		"fixed":     "x",
+		"list":      []interface{}{-1.0, true},
+	})
+
+	dc = DialConfig{Component: "fan", Command: "DoCommand"}
+	cmd, err = dc.payload(dialTurn{value: 7})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cmd, test.ShouldResemble, map[string]interface{}{"DoCommand": 7.0})
+
+	dc.Payload = map[string]interface{}{"x": "{{.speed}}"}
+	test.That(t, dc.Validate(), test.ShouldNotBeNil)
+}
@@ -0,0 +1,111 @@
+package viamstreamdeck
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+// diffDeck renders every key itself and remembers what is on the device,
+// so redrawing a key that hasn't changed doesn't go over usb.
+type diffDeck struct {
+	Deck
+	conf streamdeck.Config
+
+	lock       sync.Mutex
+	shown      map[int]*image.RGBA
+	shownTouch map[image.Point]*image.RGBA
+}
+
+func newDiffDeck(d Deck, conf streamdeck.Config) *diffDeck {
+	return &diffDeck{Deck: d, conf: conf, shown: map[int]*image.RGBA{}, shownTouch: map[image.Point]*image.RGBA{}}
+}
+
+func (dd *diffDeck) WriteText(btnIndex int, textBtn streamdeck.TextButton) error {
+	img := newButtonImage(dd.conf.ButtonSize, image.NewUniform(textBtn.BgColor))
+	err := drawTextLines(img, textBtn.Lines)
+	if err != nil {
+		return err
+	}
+	return dd.show(btnIndex, img)
+}
+
+func (dd *diffDeck) WriteTextOnImage(btnIndex int, imgIn image.Image, lines []streamdeck.TextLine) error {
+	img := resizeImage(imgIn, dd.conf.ButtonSize, dd.conf.ButtonSize)
+	err := drawTextLines(img, lines)
+	if err != nil {
+		return err
+	}
+	return dd.show(btnIndex, img)
+}
+
+func (dd *diffDeck) FillImage(btnIndex int, img image.Image) error {
+	if img.Bounds().Dx() != dd.conf.ButtonSize || img.Bounds().Dy() != dd.conf.ButtonSize {
+		return dd.show(btnIndex, resizeImage(img, dd.conf.ButtonSize, dd.conf.ButtonSize))
+	}
+	return dd.show(btnIndex, newButtonImage(dd.conf.ButtonSize, img))
+}
+
+func (dd *diffDeck) ClearBtn(btnIndex int) error {
+	return dd.show(btnIndex, newButtonImage(dd.conf.ButtonSize, image.NewUniform(color.Black)))
+}
+
+func (dd *diffDeck) ClearAllBtns() error {
+	for i := 0; i < dd.conf.NumButtons(); i++ {
+		err := dd.ClearBtn(i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dd *diffDeck) FillTouchStrip(at image.Point, img image.Image) error {
+	rgba := image.NewRGBA(image.Rectangle{Max: img.Bounds().Size()})
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	dd.lock.Lock()
+	defer dd.lock.Unlock()
+
+	old, ok := dd.shownTouch[at]
+	if ok && old.Bounds() == rgba.Bounds() && bytes.Equal(old.Pix, rgba.Pix) {
+		return nil
+	}
+
+	err := dd.Deck.FillTouchStrip(at, rgba)
+	if err != nil {
+		delete(dd.shownTouch, at)
+		return err
+	}
+	dd.shownTouch[at] = rgba
+	return nil
+}
+
+func (dd *diffDeck) attached() bool {
+	pc, ok := dd.Deck.(presenceChecker)
+	return !ok || pc.attached()
+}
+
+// show writes img to the key unless the key already shows exactly that
+func (dd *diffDeck) show(btnIndex int, img *image.RGBA) error {
+	dd.lock.Lock()
+	defer dd.lock.Unlock()
+
+	old, ok := dd.shown[btnIndex]
+	if ok && bytes.Equal(old.Pix, img.Pix) {
+		return nil
+	}
+
+	err := dd.Deck.FillImage(btnIndex, img)
+	if err != nil {
+		// we don't know what the key shows now
+		delete(dd.shown, btnIndex)
+		return err
+	}
+	dd.shown[btnIndex] = img
+	return nil
+}
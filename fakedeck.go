@@ -0,0 +1,344 @@
+package viamstreamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+// FakeDeck is an in-memory Deck for running without hardware.
+// It keeps a framebuffer per key and for the touch strip, and lets callers inject key, dial and touch events.
+type FakeDeck struct {
+	conf       streamdeck.Config
+	numDials   int
+	touchStrip image.Point
+
+	lock        sync.Mutex
+	keys        []*image.RGBA
+	writes      []int
+	touch       *image.RGBA // nil if there is no touch strip
+	touchWrites int
+	brightness  uint16
+	cb          streamdeck.BtnEvent
+	state       streamdeck.State
+	closed      bool
+	unplugged   bool
+}
+
+// NewFakeDeck makes a fake with conf's keys, and the dials and touch strip of the model with the same product id
+func NewFakeDeck(conf streamdeck.Config) *FakeDeck {
+	fd := &FakeDeck{
+		conf:   conf,
+		keys:   make([]*image.RGBA, conf.NumButtons()),
+		writes: make([]int, conf.NumButtons()),
+	}
+	for _, ms := range Models {
+		if ms.Conf.ProductID == conf.ProductID {
+			fd.numDials = ms.NumDials
+			fd.touchStrip = ms.TouchStrip
+			break
+		}
+	}
+
+	fd.state.Keys = make([]bool, conf.NumButtons())
+	for i := range fd.keys {
+		fd.keys[i] = newButtonImage(conf.ButtonSize, image.Black)
+	}
+	fd.clearTouch()
+	return fd
+}
+
+func (fd *FakeDeck) clearTouch() {
+	if fd.touchStrip.X > 0 {
+		fd.touch = image.NewRGBA(image.Rectangle{Max: fd.touchStrip})
+		draw.Draw(fd.touch, fd.touch.Bounds(), image.Black, image.Point{}, draw.Src)
+	}
+}
+
+// Model returns a ModelSetup that opens this fake instead of a usb device
+func (fd *FakeDeck) Model() *ModelSetup {
+	return &ModelSetup{
+		Model:      NamespaceFamily.WithModel("streamdeck-fake"),
+		Conf:       fd.conf,
+		NumDials:   fd.numDials,
+		TouchStrip: fd.touchStrip,
+		Open: func(ms *ModelSetup, conf *Config) (Deck, error) {
+			fd.lock.Lock()
+			defer fd.lock.Unlock()
+			if fd.unplugged {
+				return nil, fmt.Errorf("no fake streamdeck found")
+			}
+			fd.closed = false
+			return fd, nil
+		},
+	}
+}
+
+func (fd *FakeDeck) checkKey(btnIndex int) error {
+	if btnIndex < 0 || btnIndex >= len(fd.keys) {
+		return fmt.Errorf("invalid key index %d", btnIndex)
+	}
+	return fd.checkOpen()
+}
+
+func (fd *FakeDeck) checkOpen() error {
+	if fd.unplugged {
+		return ErrDeckDisconnected
+	}
+	if fd.closed {
+		return fmt.Errorf("fake deck closed")
+	}
+	return nil
+}
+
+func (fd *FakeDeck) attached() bool {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	return !fd.unplugged
+}
+
+func (fd *FakeDeck) WriteText(btnIndex int, textBtn streamdeck.TextButton) error {
+	return fd.WriteTextOnImage(btnIndex, image.NewUniform(textBtn.BgColor), textBtn.Lines)
+}
+
+func (fd *FakeDeck) WriteTextOnImage(btnIndex int, imgIn image.Image, lines []streamdeck.TextLine) error {
+	var img *image.RGBA
+	if u, ok := imgIn.(*image.Uniform); ok {
+		img = newButtonImage(fd.conf.ButtonSize, u)
+	} else {
+		img = resizeImage(imgIn, fd.conf.ButtonSize, fd.conf.ButtonSize)
+	}
+
+	err := drawTextLines(img, lines)
+	if err != nil {
+		return err
+	}
+	return fd.FillImage(btnIndex, img)
+}
+
+func (fd *FakeDeck) FillImage(btnIndex int, img image.Image) error {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+
+	if err := fd.checkKey(btnIndex); err != nil {
+		return err
+	}
+
+	fd.writes[btnIndex]++
+
+	if img.Bounds().Dx() != fd.conf.ButtonSize || img.Bounds().Dy() != fd.conf.ButtonSize {
+		fd.keys[btnIndex] = resizeImage(img, fd.conf.ButtonSize, fd.conf.ButtonSize)
+		return nil
+	}
+
+	fd.keys[btnIndex] = newButtonImage(fd.conf.ButtonSize, img)
+	return nil
+}
+
+func (fd *FakeDeck) ClearBtn(btnIndex int) error {
+	return fd.FillImage(btnIndex, newButtonImage(fd.conf.ButtonSize, image.NewUniform(color.Black)))
+}
+
+func (fd *FakeDeck) ClearAllBtns() error {
+	for i := range fd.keys {
+		err := fd.ClearBtn(i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fd *FakeDeck) FillTouchStrip(at image.Point, img image.Image) error {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+
+	if fd.touch == nil {
+		return errNoTouchStrip
+	}
+	if err := fd.checkOpen(); err != nil {
+		return err
+	}
+
+	fd.touchWrites++
+	draw.Draw(fd.touch, img.Bounds().Sub(img.Bounds().Min).Add(at), img, img.Bounds().Min, draw.Src)
+	return nil
+}
+
+func (fd *FakeDeck) SetBrightness(b uint16) error {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	if err := fd.checkOpen(); err != nil {
+		return err
+	}
+	fd.brightness = b
+	return nil
+}
+
+func (fd *FakeDeck) SetBtnEventCb(ev streamdeck.BtnEvent) {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	fd.cb = ev
+}
+
+func (fd *FakeDeck) Close() error {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	fd.closed = true
+	return nil
+}
+
+// Unplug simulates the usb cable being pulled, the device forgets what it was showing
+func (fd *FakeDeck) Unplug() {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	fd.unplugged = true
+	fd.brightness = 0
+	for i := range fd.keys {
+		fd.keys[i] = newButtonImage(fd.conf.ButtonSize, image.Black)
+	}
+	fd.clearTouch()
+}
+
+// Plug makes an unplugged device available to be opened again
+func (fd *FakeDeck) Plug() {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	fd.unplugged = false
+}
+
+// Key returns a copy of what is currently displayed on a key
+func (fd *FakeDeck) Key(btnIndex int) image.Image {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	return newButtonImage(fd.conf.ButtonSize, fd.keys[btnIndex])
+}
+
+// TouchStrip returns a copy of what is currently displayed on the touch strip, nil if there is none
+func (fd *FakeDeck) TouchStrip() *image.RGBA {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	if fd.touch == nil {
+		return nil
+	}
+	img := image.NewRGBA(fd.touch.Bounds())
+	copy(img.Pix, fd.touch.Pix)
+	return img
+}
+
+// TouchWrites returns how many times the touch strip has been written to
+func (fd *FakeDeck) TouchWrites() int {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	return fd.touchWrites
+}
+
+// Writes returns how many times a key has been written to
+func (fd *FakeDeck) Writes(btnIndex int) int {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	return fd.writes[btnIndex]
+}
+
+func (fd *FakeDeck) Brightness() uint16 {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	return fd.brightness
+}
+
+func (fd *FakeDeck) Closed() bool {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+	return fd.closed
+}
+
+// PressKey sends a key pressed event to the callback, synchronously
+func (fd *FakeDeck) PressKey(which int) {
+	fd.keyEvent(which, true)
+}
+
+// ReleaseKey sends a key released event to the callback, synchronously
+func (fd *FakeDeck) ReleaseKey(which int) {
+	fd.keyEvent(which, false)
+}
+
+// ClickKey presses and releases a key
+func (fd *FakeDeck) ClickKey(which int) {
+	fd.PressKey(which)
+	fd.ReleaseKey(which)
+}
+
+func (fd *FakeDeck) keyEvent(which int, pressed bool) {
+	kind := streamdeck.EventKind(streamdeck.EventKeyReleased)
+	if pressed {
+		kind = streamdeck.EventKeyPressed
+	}
+
+	fd.lock.Lock()
+	fd.state.Keys[which] = pressed
+	fd.lock.Unlock()
+
+	fd.send(streamdeck.Event{Kind: kind, Which: which})
+}
+
+// TurnDial moves a dial by delta ticks and sends a dial turn event, synchronously
+func (fd *FakeDeck) TurnDial(which, delta int) {
+	fd.lock.Lock()
+	for len(fd.state.DialPos) <= which {
+		fd.state.DialPos = append(fd.state.DialPos, dialStartPos)
+	}
+	fd.state.DialPos[which] += delta
+	fd.lock.Unlock()
+
+	fd.send(streamdeck.Event{Kind: streamdeck.EventDialTurn, Which: which})
+}
+
+// PressDial pushes a dial in and sends a dial pressed event, synchronously
+func (fd *FakeDeck) PressDial(which int) {
+	fd.dialPushEvent(which, true)
+}
+
+// ReleaseDial lets a dial out and sends a dial released event, synchronously
+func (fd *FakeDeck) ReleaseDial(which int) {
+	fd.dialPushEvent(which, false)
+}
+
+func (fd *FakeDeck) dialPushEvent(which int, pressed bool) {
+	kind := streamdeck.EventKind(streamdeck.EventDialReleased)
+	if pressed {
+		kind = streamdeck.EventDialPressed
+	}
+
+	fd.lock.Lock()
+	for len(fd.state.DialPush) <= which {
+		fd.state.DialPush = append(fd.state.DialPush, false)
+	}
+	fd.state.DialPush[which] = pressed
+	fd.lock.Unlock()
+
+	fd.send(streamdeck.Event{Kind: kind, Which: which})
+}
+
+// Touch sends a touch strip event, one of EventTouchTap etc, for a segment, synchronously
+func (fd *FakeDeck) Touch(kind streamdeck.EventKind, segment int) {
+	fd.send(streamdeck.Event{Kind: kind, Which: segment})
+}
+
+func (fd *FakeDeck) send(e streamdeck.Event) {
+	fd.lock.Lock()
+	cb := fd.cb
+	s := streamdeck.State{
+		Keys:     append([]bool{}, fd.state.Keys...),
+		DialPush: append([]bool{}, fd.state.DialPush...),
+		DialPos:  append([]int{}, fd.state.DialPos...),
+	}
+	fd.lock.Unlock()
+
+	if cb != nil {
+		cb(s, e)
+	}
+}
@@ -0,0 +1,172 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+// FeedbackConfig flashes a key, or the touch strip above a dial, green or red after its action runs
+type FeedbackConfig struct {
+	FlashMs    int  `json:"flash_ms,omitempty"`    // how long to show success or failure, defaults to 300
+	ShowErrors bool `json:"show_errors,omitempty"` // show what went wrong instead of only flashing red
+	ErrorMs    int  `json:"error_ms,omitempty"`    // how long to show errors with show_errors, defaults to 3000
+}
+
+func (fc *FeedbackConfig) Validate() error {
+	if fc.FlashMs < 0 || fc.ErrorMs < 0 {
+		return fmt.Errorf("flash_ms and error_ms can't be negative")
+	}
+	return nil
+}
+
+func (fc *FeedbackConfig) duration(err error) time.Duration {
+	if err != nil && fc.ShowErrors {
+		if fc.ErrorMs <= 0 {
+			return 3 * time.Second
+		}
+		return time.Duration(fc.ErrorMs) * time.Millisecond
+	}
+	if fc.FlashMs <= 0 {
+		return 300 * time.Millisecond
+	}
+	return time.Duration(fc.FlashMs) * time.Millisecond
+}
+
+// flash is the result of an action being shown on a key or touch strip segment
+type flash struct {
+	err   error
+	text  string // the error to show, empty unless show_errors
+	until time.Time
+}
+
+func (f flash) color() color.Color {
+	if f.err != nil {
+		return color.RGBA{0xd0, 0, 0, 0xff}
+	}
+	return color.RGBA{0, 0xa0, 0, 0xff}
+}
+
+// activeFlash returns the flash being shown on a key or segment, if there is one
+func activeFlash(flashes map[int]flash, which int) (flash, bool) {
+	f, ok := flashes[which]
+	if !ok {
+		return f, false
+	}
+	if !time.Now().Before(f.until) {
+		delete(flashes, which)
+		return f, false
+	}
+	return f, true
+}
+
+func isKeyEvent(kind streamdeck.EventKind) bool {
+	return kind == streamdeck.EventKeyPressed || kind == streamdeck.EventKeyReleased
+}
+
+// feedback shows how the action for an event went, on its key or for dials and touches its touch strip segment
+func (sdc *streamdeckComponent) feedback(e streamdeck.Event, actionErr error) {
+	if sdc.closed.Load() != 0 {
+		return
+	}
+
+	sdc.configLock.Lock()
+	defer sdc.configLock.Unlock()
+
+	fc := sdc.conf.Feedback
+	if fc == nil {
+		return
+	}
+
+	d := fc.duration(actionErr)
+	f := flash{err: actionErr, until: time.Now().Add(d)}
+	if actionErr != nil && fc.ShowErrors {
+		f.text = actionErr.Error()
+	}
+
+	ctx := context.Background()
+
+	if isKeyEvent(e.Kind) {
+		k, ok := sdc.keys[e.Which]
+		if !ok {
+			return
+		}
+		sdc.keyFlashes[e.Which] = f
+		err := sdc.updateKey(ctx, k)
+		if err != nil {
+			sdc.logger.Warnf("can't show feedback on key %d: %v", e.Which, err)
+		}
+		time.AfterFunc(d, func() {
+			if sdc.closed.Load() == 0 {
+				sdc.redrawKey(context.Background(), e.Which)
+			}
+		})
+		return
+	}
+
+	sdc.segmentFlashes[e.Which] = f
+	err := sdc.updateTouchStrip(ctx)
+	if err != nil {
+		sdc.logger.Warnf("can't show feedback on touch strip: %v", err)
+	}
+	time.AfterFunc(d, func() {
+		if sdc.closed.Load() != 0 {
+			return
+		}
+		sdc.configLock.Lock()
+		defer sdc.configLock.Unlock()
+		err := sdc.updateTouchStrip(context.Background())
+		if err != nil {
+			sdc.logger.Warnf("can't update touch strip: %v", err)
+		}
+	})
+}
+
+func (sdc *streamdeckComponent) drawKeyFlash(k KeyConfig, f flash) error {
+	text := k.Text
+	if f.text != "" {
+		text = shorten(f.text, 40)
+	}
+	return sdc.sd.WriteText(k.Key, streamdeck.TextButton{
+		Lines:   sdc.ms.SimpleTextFonts(text, "white", k.fonts()),
+		BgColor: f.color(),
+	})
+}
+
+// shorten cuts s to n characters, never in the middle of one
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
+
+// drawSegmentFlash puts a border around a touch strip segment, and the error if there is one to show
+func drawSegmentFlash(img *image.RGBA, f flash) error {
+	if f.text != "" {
+		draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+		err := drawCenteredText(img, shorten(f.text, 28), 11, img.Bounds().Dy()*55/100, color.White)
+		if err != nil {
+			return err
+		}
+	}
+
+	const width = 4
+	b := img.Bounds()
+	c := image.NewUniform(f.color())
+	for _, r := range []image.Rectangle{
+		image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Min.Y+width),
+		image.Rect(b.Min.X, b.Max.Y-width, b.Max.X, b.Max.Y),
+		image.Rect(b.Min.X, b.Min.Y, b.Min.X+width, b.Max.Y),
+		image.Rect(b.Max.X-width, b.Min.Y, b.Max.X, b.Max.Y),
+	} {
+		draw.Draw(img, r, c, image.Point{}, draw.Src)
+	}
+	return nil
+}
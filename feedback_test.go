@@ -0,0 +1,61 @@
+package viamstreamdeck
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+
+	"golang.org/x/image/colornames"
+)
+
+func TestFeedback(t *testing.T) {
+	conf := &Config{
+		Keys: []KeyConfig{
+			{Key: 0, Text: "ok", Color: "blue", Component: "foo", Method: "do_command"},
+			{Key: 1, Text: "bad", Color: "blue", Component: "foo", Method: "set_power", Args: []interface{}{.5}},
+		},
+		Dials:    []DialConfig{{Dial: 0, Component: "foo", Command: "SetPosition"}},
+		Feedback: &FeedbackConfig{FlashMs: 100, ShowErrors: true, ErrorMs: 150},
+	}
+
+	sdc, fd, _ := newTestDeck(t, conf)
+
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, keyColor(fd, 0).G, test.ShouldBeGreaterThan, 0)
+	test.That(t, keyColor(fd, 0).B, test.ShouldEqual, 0)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, keyColor(fd, 0), test.ShouldResemble, colornames.Blue)
+	})
+
+	fd.ClickKey(1)
+	waitForActions(sdc)
+	test.That(t, keyColor(fd, 1).R, test.ShouldBeGreaterThan, 0)
+	test.That(t, keyColor(fd, 1).B, test.ShouldEqual, 0)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, keyColor(fd, 1), test.ShouldResemble, colornames.Blue)
+	})
+
+	// foo isn't a switch, so the segment above the dial gets a red border
+	border := func() uint8 {
+		return fd.TouchStrip().RGBAAt(1, 1).R
+	}
+	test.That(t, border(), test.ShouldEqual, 0)
+	fd.TurnDial(0, 1)
+	waitForActions(sdc)
+	test.That(t, border(), test.ShouldBeGreaterThan, 0)
+	test.That(t, fd.TouchStrip().RGBAAt(201, 1).R, test.ShouldEqual, 0)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, border(), test.ShouldEqual, 0)
+	})
+}
+
+func TestShorten(t *testing.T) {
+	test.That(t, shorten("short", 10), test.ShouldEqual, "short")
+	test.That(t, shorten("abcdefghijkl", 10), test.ShouldEqual, "abcdefg...")
+	// cut between characters, not bytes
+	test.That(t, shorten("héllo wörld ünïcode", 10), test.ShouldEqual, "héllo w...")
+	test.That(t, shorten("日本語の長いエラーです", 8), test.ShouldEqual, "日本語の長...")
+	test.That(t, shorten("日本語", 3), test.ShouldEqual, "日本語")
+}
@@ -0,0 +1,284 @@
+package viamstreamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/dh1tw/streamdeck"
+	"github.com/golang/freetype/truetype"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/norm"
+)
+
+// fontFile is a loaded font. sf draws it, including code points above U+FFFF and color emoji.
+// tt is for the streamdeck library's text lines, and is nil for fonts freetype can't read.
+type fontFile struct {
+	tt    *truetype.Font
+	sf    *sfnt.Font
+	color *colorBitmaps // nil unless it is a color emoji font
+}
+
+// defaultFont is what keys use without a text_font
+var defaultFont = &fontFile{tt: streamdeck.MonoRegular}
+
+func parseFont(data []byte) (*fontFile, error) {
+	sf, err := opentype.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	ff := &fontFile{sf: sf}
+	ff.tt, _ = truetype.Parse(data)
+	ff.color, err = parseColorBitmaps(data)
+	if err != nil {
+		return nil, err
+	}
+	return ff, nil
+}
+
+// fontNames reads a text_font, which is a font's name or a list of them
+func fontNames(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []interface{}:
+		res := []string{}
+		for _, n := range v {
+			s, ok := n.(string)
+			if !ok {
+				return nil, fmt.Errorf("text_font has to be font names, not %T", n)
+			}
+			res = append(res, s)
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("text_font has to be a font name or a list of them, not %T", v)
+	}
+}
+
+// checkFonts makes sure every font in a text_font is loaded
+func checkFonts(v interface{}) error {
+	names, err := fontNames(v)
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		if _, ok := assetFonts[n]; !ok {
+			return fmt.Errorf("unknown font %s. Available fonts: %s", n, strings.Join(slices.Sorted(maps.Keys(assetFonts)), ", "))
+		}
+	}
+	return nil
+}
+
+// fontChain is fonts to try in order for each character
+type fontChain []*fontFile
+
+// findFonts returns the named fonts, or the default if there are none
+func findFonts(names []string) fontChain {
+	fc := fontChain{}
+	for _, n := range names {
+		if ff, ok := assetFonts[n]; ok {
+			fc = append(fc, ff)
+		}
+	}
+	if len(fc) == 0 {
+		return fontChain{defaultFont}
+	}
+	return fc
+}
+
+// face returns a face for measuring and drawing at size, faces can't be shared between goroutines
+func (fc fontChain) face(size float64) *chainFace {
+	f := &chainFace{chain: fc, size: size, picked: map[rune]int{}}
+	for _, ff := range fc {
+		f.faces = append(f.faces, ff.face(size))
+	}
+	return f
+}
+
+// face returns a face for ff alone at size
+func (ff *fontFile) face(size float64) font.Face {
+	if ff.sf == nil {
+		return truetype.NewFace(ff.tt, &truetype.Options{Size: size, DPI: 72})
+	}
+	f, err := opentype.NewFace(ff.sf, &opentype.FaceOptions{Size: size, DPI: 72})
+	if err != nil {
+		// only for bad options
+		panic(err)
+	}
+	return f
+}
+
+// has is true if ff has a glyph for r
+func (ff *fontFile) has(buf *sfnt.Buffer, r rune) bool {
+	if ff.sf == nil {
+		return ff.tt.Index(r) != 0
+	}
+	x, err := ff.sf.GlyphIndex(buf, r)
+	return err == nil && x != 0
+}
+
+// chainFace is a font.Face that draws each character with the first font of its chain that has it,
+// or the first font if none do
+type chainFace struct {
+	chain  fontChain
+	faces  []font.Face
+	size   float64
+	buf    sfnt.Buffer
+	picked map[rune]int
+}
+
+func (f *chainFace) pick(r rune) int {
+	if i, ok := f.picked[r]; ok {
+		return i
+	}
+	i := 0
+	for idx, ff := range f.chain {
+		if ff.has(&f.buf, r) {
+			i = idx
+			break
+		}
+	}
+	f.picked[r] = i
+	return i
+}
+
+func (f *chainFace) Close() error {
+	for _, face := range f.faces {
+		face.Close()
+	}
+	return nil
+}
+
+func (f *chainFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.faces[f.pick(r)].Glyph(dot, r)
+}
+
+func (f *chainFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.faces[f.pick(r)].GlyphBounds(r)
+}
+
+func (f *chainFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.faces[f.pick(r)].GlyphAdvance(r)
+}
+
+// Kern only kerns characters drawn with the same font
+func (f *chainFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	i := f.pick(r0)
+	if f.pick(r1) != i {
+		return 0
+	}
+	return f.faces[i].Kern(r0, r1)
+}
+
+// Metrics are the first font's, tall enough for all of them
+func (f *chainFace) Metrics() font.Metrics {
+	m := f.faces[0].Metrics()
+	for _, face := range f.faces[1:] {
+		o := face.Metrics()
+		m.Height = max(m.Height, o.Height)
+		m.Ascent = max(m.Ascent, o.Ascent)
+		m.Descent = max(m.Descent, o.Descent)
+	}
+	return m
+}
+
+// invisibleRune is true for code points that only change how their neighbors look,
+// like variation selectors and zero width joiners
+func invisibleRune(r rune) bool {
+	return r == 0x200c || r == 0x200d || unicode.Is(unicode.Variation_Selector, r) || (r >= 0xe0020 && r <= 0xe007f)
+}
+
+// placedGlyph is a code point and how far along the line it is drawn
+type placedGlyph struct {
+	r rune
+	x fixed.Int26_6
+}
+
+// placeGlyphs returns where each code point of s goes, and how wide s is.
+// Combining marks go on the code point before them. Sequences that need shaping,
+// like emoji joined with U+200D, are drawn as their parts.
+func placeGlyphs(face font.Face, s string) ([]placedGlyph, fixed.Int26_6) {
+	res := []placedGlyph{}
+	x, prevX := fixed.Int26_6(0), fixed.Int26_6(0)
+	prev := rune(-1)
+	for _, r := range norm.NFC.String(s) {
+		if invisibleRune(r) {
+			continue
+		}
+		adv, _ := face.GlyphAdvance(r)
+		if unicode.Is(unicode.Mn, r) {
+			// fonts with zero width marks place them from where the base glyph ends
+			at := x
+			if adv != 0 {
+				at = prevX
+			}
+			res = append(res, placedGlyph{r, at})
+			continue
+		}
+		if prev >= 0 {
+			x += face.Kern(prev, r)
+		}
+		res = append(res, placedGlyph{r, x})
+		prevX = x
+		x += adv
+		prev = r
+	}
+	return res, x
+}
+
+// drawString draws s starting at x on baseline y, each character in the font the face picks for it
+func (f *chainFace) drawString(dst *image.RGBA, x, y int, s string, clr color.Color) {
+	src := image.NewUniform(clr)
+	glyphs, _ := placeGlyphs(f, s)
+	for _, g := range glyphs {
+		i := f.pick(g.r)
+		dot := fixed.Point26_6{X: fixed.I(x) + g.x, Y: fixed.I(y)}
+		if f.chain[i].drawColorGlyph(dst, f.size, dot, g.r) {
+			continue
+		}
+		dr, mask, maskp, _, ok := f.faces[i].Glyph(dot, g.r)
+		if ok {
+			draw.DrawMask(dst, dr, src, image.Point{}, mask, maskp, draw.Over)
+		}
+	}
+}
+
+// drawColorGlyph draws r from a color emoji font scaled to size, it returns false if the font has no bitmap for r
+func (ff *fontFile) drawColorGlyph(dst *image.RGBA, size float64, dot fixed.Point26_6, r rune) bool {
+	if ff.color == nil {
+		return false
+	}
+	var buf sfnt.Buffer
+	x, err := ff.sf.GlyphIndex(&buf, r)
+	if err != nil || x == 0 {
+		return false
+	}
+	b, err := ff.color.glyph(x)
+	if err != nil || b == nil {
+		return false
+	}
+
+	scale := size / float64(ff.color.ppem)
+	at := func(v int) int { return int(math.Round(float64(v) * scale)) }
+	left := dot.X.Round() + at(b.bearingX)
+	top := dot.Y.Round() - at(b.bearingY)
+	rect := image.Rect(left, top, left+at(b.img.Bounds().Dx()), top+at(b.img.Bounds().Dy()))
+	xdraw.CatmullRom.Scale(dst, rect, b.img, b.img.Bounds(), xdraw.Over, nil)
+	return true
+}
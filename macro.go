@@ -0,0 +1,143 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+// MacroStep is one step of a key's macro. It calls a method on a component, or changes page,
+// or if it has neither it only waits.
+type MacroStep struct {
+	Component string        `json:"component,omitempty"`
+	Method    string        `json:"method,omitempty"`
+	Args      []interface{} `json:"args,omitempty"`
+
+	SetPage string `json:"set_page,omitempty"`
+
+	WaitMs          int              `json:"wait_ms,omitempty"` // before the step
+	If              *ConditionConfig `json:"if,omitempty"`
+	ContinueOnError bool             `json:"continue_on_error,omitempty"`
+}
+
+// ConditionConfig is true when Template, filled in with Sensor's readings, comes out as "true"
+type ConditionConfig struct {
+	Sensor   string `json:"sensor"`
+	Template string `json:"template"`
+}
+
+func (ms *MacroStep) Validate() error {
+	if ms.Component != "" && ms.SetPage != "" {
+		return fmt.Errorf("can't have both a component and set_page")
+	}
+	if ms.Component != "" {
+		if err := ms.action().Validate(); err != nil {
+			return err
+		}
+	} else if ms.Method != "" {
+		return fmt.Errorf("need a component for method %s", ms.Method)
+	}
+	if ms.Component == "" && ms.SetPage == "" && ms.WaitMs <= 0 {
+		return fmt.Errorf("need a component, set_page or wait_ms")
+	}
+	if ms.WaitMs < 0 {
+		return fmt.Errorf("wait_ms can't be negative")
+	}
+	if ms.If != nil {
+		if ms.If.Sensor == "" {
+			return fmt.Errorf("condition needs a sensor")
+		}
+		if _, err := parseKeyTemplate(ms.If.Template); err != nil {
+			return fmt.Errorf("bad condition template: %w", err)
+		}
+	}
+	return nil
+}
+
+func (ms *MacroStep) action() *ActionConfig {
+	return &ActionConfig{Component: ms.Component, Method: ms.Method, Args: ms.Args}
+}
+
+// startMacro runs a key's macro on the key's worker, timeout_ms is for the whole macro
+func (sdc *streamdeckComponent) startMacro(k KeyConfig, e streamdeck.Event) {
+	sdc.runKeyAction(k, k.policy(), func(ctx context.Context) {
+		err := sdc.runMacro(ctx, k.Macro)
+		sdc.feedback(e, err)
+		if err != nil {
+			sdc.logger.Errorf("macro for key %d failed: %v", k.Key, err)
+		}
+	})
+}
+
+func (sdc *streamdeckComponent) runMacro(ctx context.Context, steps []MacroStep) error {
+	for i, step := range steps {
+		if step.If != nil {
+			ok, err := sdc.checkCondition(ctx, step.If)
+			if err != nil {
+				err = fmt.Errorf("step %d condition: %w", i, err)
+				if !step.ContinueOnError {
+					return err
+				}
+				sdc.logger.Warnf("%v", err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if step.WaitMs > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(step.WaitMs) * time.Millisecond):
+			}
+		}
+
+		err := sdc.runMacroStep(ctx, step)
+		if err != nil {
+			err = fmt.Errorf("step %d: %w", i, err)
+			if !step.ContinueOnError {
+				return err
+			}
+			sdc.logger.Warnf("%v", err)
+		}
+	}
+	return nil
+}
+
+func (sdc *streamdeckComponent) runMacroStep(ctx context.Context, step MacroStep) error {
+	if step.SetPage != "" {
+		return sdc.setPage(ctx, step.SetPage)
+	}
+	if step.Component == "" {
+		// only waits
+		return nil
+	}
+	res, err := sdc.runAction(ctx, step.action())
+	if err != nil {
+		return err
+	}
+	sdc.logger.Debugf("macro step %s %s got result %v", step.Component, step.Method, res)
+	return nil
+}
+
+// checkCondition reads the sensor now, without configLock so a slow sensor doesn't hold up the deck
+func (sdc *streamdeckComponent) checkCondition(ctx context.Context, c *ConditionConfig) (bool, error) {
+	sdc.configLock.Lock()
+	deps := sdc.deps
+	sdc.configLock.Unlock()
+
+	readings, err := doRead(ctx, deps, readingsRead(c.Sensor, 0))
+	if err != nil {
+		return false, err
+	}
+	text, err := renderTemplate(c.Template, readings)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(text) == "true", nil
+}
@@ -0,0 +1,75 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/rdk/components/generic"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+func TestMacro(t *testing.T) {
+	step := func(x interface{}) MacroStep {
+		return MacroStep{Component: "foo", Method: "do_command", Args: []interface{}{map[string]interface{}{"step": x}}}
+	}
+	hot := step("hot")
+	hot.If = &ConditionConfig{Sensor: "temp", Template: "{{gt .temperature 30.0}}"}
+	wait := step(3.0)
+	wait.WaitMs = 20
+
+	conf := &Config{
+		InitialPage: "a",
+		Pages: map[string][]KeyConfig{
+			"a": {{Key: 0, Text: "go", Macro: []MacroStep{
+				step(1.0),
+				hot,
+				{Component: "foo", Method: "set_power", Args: []interface{}{.5}, ContinueOnError: true},
+				wait,
+				{SetPage: "b"},
+				{Component: "foo", Method: "set_power", Args: []interface{}{.5}},
+				step("never"),
+			}}},
+			"b": {{Key: 0, Text: "b", Component: "foo", Method: "do_command"}},
+		},
+	}
+	_, _, err := conf.Validate("")
+	test.That(t, err, test.ShouldBeNil)
+
+	thing := &testThing{name: generic.Named("foo")}
+	ts := &testSensor{testThing: testThing{name: generic.Named("temp")}, readings: map[string]interface{}{"temperature": 21.0}}
+
+	fd := NewFakeDeck(streamdeck.Plus)
+	deps := resource.Dependencies{thing.name: thing, ts.name: ts}
+	r, err := NewStreamDeck(context.Background(), generic.Named("deck"), deps, fd.Model(), conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer r.Close(context.Background())
+	sdc := r.(*streamdeckComponent)
+
+	fd.ClickKey(0)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		sdc.configLock.Lock()
+		defer sdc.configLock.Unlock()
+		test.That(tb, sdc.currentPage, test.ShouldEqual, "b")
+	})
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		sdc.pressLock.Lock()
+		defer sdc.pressLock.Unlock()
+		test.That(tb, sdc.presses[0].run, test.ShouldBeNil)
+	})
+	test.That(t, thing.commands(), test.ShouldResemble, []map[string]interface{}{{"step": 1.0}, {"step": 3.0}})
+
+	for _, ms := range []MacroStep{
+		{},
+		{Method: "stop"},
+		{Component: "foo", SetPage: "b"},
+		{Component: "foo", Method: "fly"},
+		{WaitMs: 10, If: &ConditionConfig{Template: "true"}},
+	} {
+		test.That(t, ms.Validate(), test.ShouldNotBeNil)
+	}
+}
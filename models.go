@@ -3,6 +3,9 @@ package viamstreamdeck
 import (
 	"context"
 	"fmt"
+	"image"
+	"slices"
+	"strings"
 
 	"go.viam.com/rdk/logging"
 	"go.viam.com/rdk/resource"
@@ -17,20 +20,104 @@ var NamespaceFamily = resource.ModelNamespace("erh").WithFamily("viam-streamdeck
 type ModelSetup struct {
 	Model resource.Model
 	Conf  streamdeck.Config
+
+	// AltProductIDs are other hardware revisions with the same layout and protocol
+	AltProductIDs []uint16
+
+	NumDials int
+
+	// TouchStrip is the size of the touch screen above the dials, zero if there is none.
+	// It is split into one segment per dial.
+	TouchStrip image.Point
+
+	// Open connects to the device, if nil the usb device matching Conf is used
+	Open func(ms *ModelSetup, conf *Config) (Deck, error)
 }
 
-var ModelPlus = &ModelSetup{NamespaceFamily.WithModel("streamdeck-plus"), streamdeck.Plus}
-var ModelOriginal = &ModelSetup{NamespaceFamily.WithModel("streamdeck-original"), streamdeck.Original}
-var ModelOriginal2 = &ModelSetup{NamespaceFamily.WithModel("streamdeck-original2"), streamdeck.Original2}
+var ModelPlus = &ModelSetup{
+	Model:      NamespaceFamily.WithModel("streamdeck-plus"),
+	Conf:       streamdeck.Plus,
+	NumDials:   4,
+	TouchStrip: image.Pt(800, 100),
+	Open:       usbOpener(usbProtocolV2, keyTransform{}),
+}
+var ModelOriginal = &ModelSetup{Model: NamespaceFamily.WithModel("streamdeck-original"), Conf: streamdeck.Original}
+var ModelOriginal2 = &ModelSetup{Model: NamespaceFamily.WithModel("streamdeck-original2"), Conf: streamdeck.Original2}
+
+var ModelMini = &ModelSetup{
+	Model: NamespaceFamily.WithModel("streamdeck-mini"),
+	Conf: streamdeck.Config{
+		ProductID:        0x63,
+		NumButtonColumns: 3,
+		NumButtonRows:    2,
+		ButtonSize:       80,
+		ImageFormat:      "bmp",
+	},
+	AltProductIDs: []uint16{0x90}, // mini mk.2
+	Open:          usbOpener(usbProtocolV1, keyTransform{Rotate: 90, FlipV: true}),
+}
+
+var ModelXL = &ModelSetup{
+	Model: NamespaceFamily.WithModel("streamdeck-xl"),
+	Conf: streamdeck.Config{
+		ProductID:        0x6c,
+		NumButtonColumns: 8,
+		NumButtonRows:    4,
+		ButtonSize:       96,
+		ImageFormat:      "jpg",
+	},
+	AltProductIDs: []uint16{0x8f}, // xl v2
+	Open:          usbOpener(usbProtocolV2, keyTransform{FlipH: true, FlipV: true}),
+}
+
+// ModelMK2 is the 15 key revisions the streamdeck library doesn't know, the 0x80 MK.2 itself is ModelOriginal2
+var ModelMK2 = &ModelSetup{
+	Model: NamespaceFamily.WithModel("streamdeck-mk2"),
+	Conf: streamdeck.Config{
+		ProductID:        0xa5, // mk.2 scissor keys
+		NumButtonColumns: 5,
+		NumButtonRows:    3,
+		ButtonSize:       72,
+		ImageFormat:      "jpg",
+	},
+	AltProductIDs: []uint16{0x6d}, // original v2
+	Open:          usbOpener(usbProtocolV2, keyTransform{FlipH: true, FlipV: true}),
+}
+
+// ModelNeo only exposes the 8 keys, not the info screen or the two touch points
+var ModelNeo = &ModelSetup{
+	Model: NamespaceFamily.WithModel("streamdeck-neo"),
+	Conf: streamdeck.Config{
+		ProductID:        0x9a,
+		NumButtonColumns: 4,
+		NumButtonRows:    2,
+		ButtonSize:       96,
+		ImageFormat:      "jpg",
+	},
+	Open: usbOpener(usbProtocolV2, keyTransform{FlipH: true, FlipV: true}),
+}
 
 var Models = []*ModelSetup{
 	ModelPlus,
 	ModelOriginal,
 	ModelOriginal2,
+	ModelMini,
+	ModelXL,
+	ModelMK2,
+	ModelNeo,
 }
 
 var ModelAny = NamespaceFamily.WithModel("streamdeck-any")
 
+// ModelVirtual serves a deck on a local web page instead of using usb, it mimics the layout of one of Models
+var ModelVirtual = &ModelSetup{
+	Model:      NamespaceFamily.WithModel("streamdeck-virtual"),
+	Conf:       streamdeck.Plus,
+	NumDials:   4,
+	TouchStrip: image.Pt(800, 100),
+	Open:       openVirtualDeck,
+}
+
 func init() {
 	for _, ms := range Models {
 		resource.RegisterService(generic.API, ms.Model, resource.Registration[resource.Resource, *Config]{
@@ -48,28 +135,133 @@ func init() {
 
 	resource.RegisterService(generic.API, ModelAny, resource.Registration[resource.Resource, *Config]{
 		Constructor: func(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger logging.Logger) (resource.Resource, error) {
-			ms := FindAttachedStreamDeck()
-			if ms == nil {
-				return nil, fmt.Errorf("no streamdeck found")
+			newConf, err := resource.NativeConfig[*Config](conf)
+			if err != nil {
+				return nil, err
+			}
+
+			ms, err := pickStreamDeck(ListAttachedStreamDecks(), newConf.Serial)
+			if err != nil {
+				return nil, err
 			}
 
+			return NewStreamDeck(ctx, conf.ResourceName(), deps, ms, newConf, logger)
+		},
+	})
+
+	resource.RegisterService(generic.API, ModelVirtual.Model, resource.Registration[resource.Resource, *Config]{
+		Constructor: func(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger logging.Logger) (resource.Resource, error) {
 			newConf, err := resource.NativeConfig[*Config](conf)
 			if err != nil {
 				return nil, err
 			}
 
+			ms, err := VirtualModel(newConf)
+			if err != nil {
+				return nil, err
+			}
+
 			return NewStreamDeck(ctx, conf.ResourceName(), deps, ms, newConf, logger)
 		},
 	})
+}
+
+// open connects to the deck, keys are only written when their image changes
+func (ms *ModelSetup) open(conf *Config) (Deck, error) {
+	open := ms.Open
+	if open == nil {
+		open = openHardwareDeck
+	}
+	d, err := open(ms, conf)
+	if err != nil {
+		return nil, err
+	}
+	return newDiffDeck(d, ms.Conf), nil
+}
 
+// withLayout returns a copy of ms that has the layout of the named model, e.g. "plus"
+func (ms *ModelSetup) withLayout(layout string) (*ModelSetup, error) {
+	for _, m := range Models {
+		if m.Model.Name == "streamdeck-"+layout {
+			n := *ms
+			n.Conf = m.Conf
+			n.NumDials = m.NumDials
+			n.TouchStrip = m.TouchStrip
+			return &n, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown layout %s", layout)
 }
 
-func FindAttachedStreamDeck() *ModelSetup {
+func (ms *ModelSetup) productIDs() []uint16 {
+	return append([]uint16{ms.Conf.ProductID}, ms.AltProductIDs...)
+}
+
+// AttachedStreamDeck is a stream deck found on usb
+type AttachedStreamDeck struct {
+	Model  *ModelSetup
+	Serial string
+}
+
+// modelForProductID returns the model that drives a product id, nil if none does
+func modelForProductID(pid uint16) *ModelSetup {
 	for _, ms := range Models {
-		devices := hid.Enumerate(streamdeck.VendorID, ms.Conf.ProductID)
-		if len(devices) > 0 {
+		if slices.Contains(ms.productIDs(), pid) {
 			return ms
 		}
 	}
 	return nil
 }
+
+// ListAttachedStreamDecks returns every attached stream deck, as the model that drives it
+func ListAttachedStreamDecks() []AttachedStreamDeck {
+	res := []AttachedStreamDeck{}
+	for _, d := range hid.Enumerate(streamdeck.VendorID, 0) {
+		if ms := modelForProductID(d.ProductID); ms != nil {
+			res = append(res, AttachedStreamDeck{ms, d.Serial})
+		}
+	}
+	return res
+}
+
+// pickStreamDeck finds the deck with serial, if serial is empty there has to be exactly one deck
+func pickStreamDeck(attached []AttachedStreamDeck, serial string) (*ModelSetup, error) {
+	if len(attached) == 0 {
+		return nil, fmt.Errorf("no streamdeck found")
+	}
+
+	if serial != "" {
+		for _, a := range attached {
+			if a.Serial == serial {
+				return a.Model, nil
+			}
+		}
+		return nil, fmt.Errorf("no streamdeck found with serial %s, attached: %s", serial, describeAttached(attached))
+	}
+
+	if len(attached) > 1 {
+		return nil, fmt.Errorf("%d streamdecks attached, set serial to pick one: %s", len(attached), describeAttached(attached))
+	}
+
+	return attached[0].Model, nil
+}
+
+func describeAttached(attached []AttachedStreamDeck) string {
+	s := []string{}
+	for _, a := range attached {
+		s = append(s, fmt.Sprintf("%s (%s)", a.Serial, a.Model.Model.Name))
+	}
+	return strings.Join(s, ", ")
+}
+
+func FindAttachedStreamDeck() *ModelSetup {
+	for _, ms := range Models {
+		for _, pid := range ms.productIDs() {
+			devices := hid.Enumerate(streamdeck.VendorID, pid)
+			if len(devices) > 0 {
+				return ms
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package viamstreamdeck
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestPickStreamDeck(t *testing.T) {
+	_, err := pickStreamDeck(nil, "")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	attached := []AttachedStreamDeck{{ModelPlus, "left"}}
+
+	ms, err := pickStreamDeck(attached, "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ms, test.ShouldEqual, ModelPlus)
+
+	attached = append(attached, AttachedStreamDeck{ModelXL, "right"})
+
+	_, err = pickStreamDeck(attached, "")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "set serial")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "right (streamdeck-xl)")
+
+	ms, err = pickStreamDeck(attached, "right")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ms, test.ShouldEqual, ModelXL)
+
+	_, err = pickStreamDeck(attached, "middle")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// an MK.2 is driven as original2, not also as mk2
+	attached = append(attached, AttachedStreamDeck{modelForProductID(0x80), "mk2"})
+	ms, err = pickStreamDeck(attached, "mk2")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ms, test.ShouldEqual, ModelOriginal2)
+	test.That(t, modelForProductID(0xa5), test.ShouldEqual, ModelMK2)
+	test.That(t, modelForProductID(0x1234), test.ShouldBeNil)
+
+	// each product id is driven by one model
+	for _, ms := range Models {
+		for _, pid := range ms.productIDs() {
+			test.That(t, modelForProductID(pid), test.ShouldEqual, ms)
+		}
+	}
+}
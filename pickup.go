@@ -136,7 +136,7 @@ func NewPickup(ctx context.Context, name resource.Name, deps resource.Dependenci
 	}
 
 	// keep this last so we don't have to close it
-	p.sd, err = streamdeck.NewStreamDeckWithConfig(&ms.Conf, "")
+	p.sd, err = ms.open(nil)
 	if err != nil {
 		return nil, err
 	}
@@ -166,7 +166,7 @@ type Pickup struct {
 	conf   *PickupConfig
 	ms     *ModelSetup
 
-	sd *streamdeck.StreamDeck
+	sd Deck
 
 	arm       arm.Arm
 	gripper   gripper.Gripper
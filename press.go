@@ -0,0 +1,195 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"time"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+// keyPress follows a key from press to release to tell long and double presses from normal ones
+type keyPress struct {
+	longTimer *time.Timer // running while held, fires the long press
+	longFired bool
+
+	doubleTimer *time.Timer // running after a release, fires the normal press if no second press comes
+	second      bool        // this press is the second of a double press
+
+	release *ActionConfig // on_release of the key when it was pressed, pending until it is let go
+
+	actionQueue
+
+	confirmTimer *time.Timer // running while the key waits for the press that confirms it
+}
+
+func (sdc *streamdeckComponent) keyPressState(which int) *keyPress {
+	kp, ok := sdc.presses[which]
+	if !ok {
+		kp = &keyPress{}
+		sdc.presses[which] = kp
+	}
+	return kp
+}
+
+func (sdc *streamdeckComponent) handleKeyDown(ctx context.Context, s streamdeck.State, e streamdeck.Event) error {
+	k, err := sdc.getKeyConfig(e.Which)
+	if err != nil {
+		// nothing to do until it is released
+		return nil
+	}
+
+	sdc.runKeyPressAction(*k, policyQueue, e, "on_press", k.OnPress)
+
+	sdc.pressLock.Lock()
+	defer sdc.pressLock.Unlock()
+
+	kp := sdc.keyPressState(e.Which)
+	kp.longFired = false
+	kp.release = k.OnRelease
+
+	if kp.doubleTimer != nil {
+		kp.doubleTimer.Stop()
+		kp.doubleTimer = nil
+		kp.second = true
+	}
+
+	if k.LongPress != nil {
+		var t *time.Timer
+		t = time.AfterFunc(k.longPressDuration(), func() {
+			sdc.pressLock.Lock()
+			if kp.longTimer != t {
+				// released or closed first
+				sdc.pressLock.Unlock()
+				return
+			}
+			kp.longTimer = nil
+			kp.longFired = true
+			kp.second = false
+			sdc.pressLock.Unlock()
+
+			sdc.runKeyPressAction(*k, k.policy(), e, "long_press", k.LongPress)
+		})
+		kp.longTimer = t
+	}
+
+	return nil
+}
+
+func (sdc *streamdeckComponent) handleKeyUp(ctx context.Context, s streamdeck.State, e streamdeck.Event) error {
+	// stop whatever on_press started before anything else, even if the key has changed since
+	sdc.pressLock.Lock()
+	release := sdc.keyPressState(e.Which).release
+	sdc.keyPressState(e.Which).release = nil
+	sdc.pressLock.Unlock()
+
+	k, err := sdc.getKeyConfig(e.Which)
+	if err != nil {
+		sdc.runKeyPressAction(KeyConfig{Key: e.Which}, policyAlways, e, "on_release", release)
+		return err
+	}
+	sdc.runKeyPressAction(*k, policyAlways, e, "on_release", release)
+
+	sdc.pressLock.Lock()
+	kp := sdc.keyPressState(e.Which)
+
+	if kp.longTimer != nil {
+		kp.longTimer.Stop()
+		kp.longTimer = nil
+	}
+
+	if kp.longFired {
+		kp.longFired = false
+		sdc.pressLock.Unlock()
+		return nil
+	}
+
+	if kp.second {
+		kp.second = false
+		sdc.pressLock.Unlock()
+		sdc.runKeyPressAction(*k, k.policy(), e, "double_press", k.DoublePress)
+		return nil
+	}
+
+	if k.DoublePress != nil {
+		var t *time.Timer
+		t = time.AfterFunc(k.doublePressDuration(), func() {
+			sdc.pressLock.Lock()
+			if kp.doubleTimer != t {
+				sdc.pressLock.Unlock()
+				return
+			}
+			kp.doubleTimer = nil
+			sdc.pressLock.Unlock()
+
+			err := sdc.handleKeyPress(context.Background(), s, e, e.Which)
+			if err != nil {
+				sdc.logger.Errorf("event handler failed for event %v: %v", e, err)
+			}
+		})
+		kp.doubleTimer = t
+		sdc.pressLock.Unlock()
+		return nil
+	}
+
+	sdc.pressLock.Unlock()
+	return sdc.handleKeyPress(ctx, s, e, e.Which)
+}
+
+// runKeyPressAction runs one of a key's actions on the key's worker, on_press and on_release queue so they stay in order
+func (sdc *streamdeckComponent) runKeyPressAction(k KeyConfig, policy string, e streamdeck.Event, kind string, ac *ActionConfig) {
+	if ac == nil {
+		return
+	}
+	sdc.runKeyAction(k, policy, func(ctx context.Context) {
+		sdc.runPressAction(ctx, e, kind, ac)
+	})
+}
+
+func (sdc *streamdeckComponent) runPressAction(ctx context.Context, e streamdeck.Event, kind string, ac *ActionConfig) {
+	if ac == nil {
+		return
+	}
+	res, err := sdc.runAction(ctx, ac)
+	sdc.feedback(e, err)
+	if err != nil {
+		sdc.logger.Errorf("%s for event %v failed: %v", kind, e, err)
+		return
+	}
+	sdc.logger.Infof("event %v %s got result %v", e, kind, res)
+}
+
+// stopPresses drops any long or double presses still waiting to fire, and any waiting confirmations
+func (sdc *streamdeckComponent) stopPresses() {
+	sdc.cancelConfirms()
+
+	sdc.pressLock.Lock()
+	defer sdc.pressLock.Unlock()
+	for _, kp := range sdc.presses {
+		if kp.longTimer != nil {
+			kp.longTimer.Stop()
+			kp.longTimer = nil
+		}
+		if kp.doubleTimer != nil {
+			kp.doubleTimer.Stop()
+			kp.doubleTimer = nil
+		}
+	}
+}
+
+// releaseHeldKeys runs on_release for keys that are down, for when their release will never come
+func (sdc *streamdeckComponent) releaseHeldKeys() {
+	sdc.pressLock.Lock()
+	held := map[int]*ActionConfig{}
+	for which, kp := range sdc.presses {
+		if kp.release != nil {
+			held[which] = kp.release
+			kp.release = nil
+		}
+	}
+	sdc.pressLock.Unlock()
+
+	for which, a := range held {
+		e := streamdeck.Event{Kind: streamdeck.EventKeyReleased, Which: which}
+		sdc.runKeyPressAction(KeyConfig{Key: which}, policyAlways, e, "on_release", a)
+	}
+}
@@ -0,0 +1,136 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+
+	"golang.org/x/image/colornames"
+)
+
+func TestLongAndDoublePress(t *testing.T) {
+	conf := &Config{
+		Keys: []KeyConfig{
+			{
+				Key: 0, Text: "a", Component: "foo", Method: "do_command", Args: []interface{}{map[string]interface{}{"x": "short"}},
+				LongPress:   &ActionConfig{Component: "foo", Method: "do_command", Args: []interface{}{map[string]interface{}{"x": "long"}}},
+				LongPressMs: 50,
+			},
+			{
+				Key: 1, Text: "b", Component: "foo", Method: "do_command", Args: []interface{}{map[string]interface{}{"x": "single"}},
+				DoublePress:   &ActionConfig{Component: "foo", Method: "do_command", Args: []interface{}{map[string]interface{}{"x": "double"}}},
+				DoublePressMs: 100,
+			},
+		},
+	}
+
+	sdc, fd, thing := newTestDeck(t, conf)
+
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, thing.commands(), test.ShouldResemble, []map[string]interface{}{{"x": "short"}})
+
+	fd.PressKey(0)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, thing.commands(), test.ShouldHaveLength, 2)
+	})
+	fd.ReleaseKey(0)
+	test.That(t, thing.commands()[1], test.ShouldResemble, map[string]interface{}{"x": "long"})
+
+	fd.ClickKey(1)
+	fd.ClickKey(1)
+	waitForActions(sdc)
+	test.That(t, thing.commands(), test.ShouldHaveLength, 3)
+	test.That(t, thing.commands()[2], test.ShouldResemble, map[string]interface{}{"x": "double"})
+
+	fd.ClickKey(1)
+	test.That(t, thing.commands(), test.ShouldHaveLength, 3)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, thing.commands(), test.ShouldHaveLength, 4)
+	})
+	test.That(t, thing.commands()[3], test.ShouldResemble, map[string]interface{}{"x": "single"})
+
+	time.Sleep(150 * time.Millisecond)
+	test.That(t, thing.commands(), test.ShouldHaveLength, 4)
+
+	bad := &Config{Keys: []KeyConfig{{Key: 0, Text: "a", Component: "foo", Method: "do_command", LongPress: &ActionConfig{Component: "foo"}}}}
+	_, _, err := bad.Validate("")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestMomentaryPress(t *testing.T) {
+	conf := &Config{
+		Keys: []KeyConfig{
+			{
+				Key: 0, Text: "jog",
+				OnPress:   &ActionConfig{Component: "foo", Method: "do_command", Args: []interface{}{map[string]interface{}{"power": 0.3}}},
+				OnRelease: &ActionConfig{Component: "foo", Method: "do_command", Args: []interface{}{map[string]interface{}{"stop": true}}},
+			},
+		},
+	}
+
+	sdc, fd, thing := newTestDeck(t, conf)
+
+	fd.PressKey(0)
+	waitForActions(sdc)
+	test.That(t, thing.commands(), test.ShouldResemble, []map[string]interface{}{{"power": 0.3}})
+	fd.ReleaseKey(0)
+	waitForActions(sdc)
+	test.That(t, thing.commands(), test.ShouldResemble, []map[string]interface{}{{"power": 0.3}, {"stop": true}})
+
+	// losing the deck while held stops it too
+	fd.PressKey(0)
+	fd.Unplug()
+	sdc.checkState(context.Background())
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, thing.commands(), test.ShouldHaveLength, 4)
+	})
+	test.That(t, thing.commands()[3], test.ShouldResemble, map[string]interface{}{"stop": true})
+	fd.Plug()
+}
+
+func TestConfirmPress(t *testing.T) {
+	conf := &Config{
+		Keys: []KeyConfig{
+			{Key: 0, Text: "home", Color: "blue", Component: "foo", Method: "do_command", Confirm: true, ConfirmMs: 100},
+		},
+	}
+
+	sdc, fd, thing := newTestDeck(t, conf)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Blue)
+
+	fd.ClickKey(0)
+	test.That(t, thing.commands(), test.ShouldHaveLength, 0)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Red)
+
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, thing.commands(), test.ShouldHaveLength, 1)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Blue)
+
+	// too slow, the first press is forgotten
+	fd.ClickKey(0)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, keyColor(fd, 0), test.ShouldResemble, colornames.Blue)
+	})
+	fd.ClickKey(0)
+	test.That(t, thing.commands(), test.ShouldHaveLength, 1)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Red)
+
+	// these would run without being confirmed
+	for _, k := range []KeyConfig{
+		{OnPress: &ActionConfig{Component: "foo", Method: "do_command"}},
+		{OnRelease: &ActionConfig{Component: "foo", Method: "do_command"}},
+		{LongPress: &ActionConfig{Component: "foo", Method: "do_command"}},
+		{DoublePress: &ActionConfig{Component: "foo", Method: "do_command"}},
+	} {
+		k.Text, k.Component, k.Method, k.Confirm = "home", "foo", "do_command", true
+		bad := &Config{Keys: []KeyConfig{k}}
+		_, _, err := bad.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "confirm")
+	}
+}
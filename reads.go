@@ -0,0 +1,238 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/erh/vmodutils"
+
+	toggleswitch "go.viam.com/rdk/components/switch"
+	"go.viam.com/rdk/resource"
+)
+
+// the names of a switch's positions, a read of this kind returns switchPositions
+const readPositions = "positions"
+
+var errNotRead = errors.New("not read yet")
+
+// resourceRead is something drawing the deck needs from a resource, the readings of a sensor, where a switch is
+// or what a DoCommand returns. Reads are done without configLock and kept, drawing only looks at what was last read
+// so a slow resource never holds up the deck.
+type resourceRead struct {
+	kind      string // readings, position, positions or do_command
+	component string
+	command   map[string]interface{} // for do_command
+	every     time.Duration          // how often it's due, 0 to read it for every full redraw
+}
+
+type switchPositions struct {
+	n     uint32
+	names []string
+}
+
+// readResult is what a read last got
+type readResult struct {
+	value interface{}
+	err   error
+	at    time.Time
+}
+
+func (rr resourceRead) id() string {
+	if rr.kind == stateSourceDoCommand {
+		cmd, _ := json.Marshal(rr.command)
+		return fmt.Sprintf("%s/%s/%s", rr.kind, rr.component, cmd)
+	}
+	return rr.kind + "/" + rr.component
+}
+
+func positionRead(component string) resourceRead {
+	return resourceRead{kind: stateSourcePosition, component: component}
+}
+
+func positionsRead(component string) resourceRead {
+	return resourceRead{kind: readPositions, component: component}
+}
+
+func readingsRead(component string, every time.Duration) resourceRead {
+	return resourceRead{kind: stateSourceReadings, component: component, every: every}
+}
+
+func (sc *StateConfig) read() resourceRead {
+	return resourceRead{kind: sc.Source, component: sc.Component, command: sc.Command}
+}
+
+// reads are what drawing a key needs, in any of its states
+func (kc *KeyConfig) reads() []resourceRead {
+	var res []resourceRead
+	variants := []KeyConfig{*kc}
+	if len(kc.States) > 0 {
+		variants = nil
+		for i := range kc.States {
+			variants = append(variants, kc.withState(i))
+		}
+	}
+	if kc.Follow != "" {
+		res = append(res, positionRead(kc.Follow))
+	}
+	if kc.Sensor != "" {
+		res = append(res, readingsRead(kc.Sensor, kc.refreshInterval()))
+	}
+	for _, k := range variants {
+		if sc, _ := k.styles(); sc != nil {
+			res = append(res, sc.read())
+		}
+		if k.Image == "" && k.Text == "" && k.isMethod("set_position") {
+			res = append(res, positionsRead(k.Component))
+		}
+	}
+	return res
+}
+
+// reads are what drawing a dial's segment of the touch strip needs
+func (dc *DialConfig) reads() []resourceRead {
+	var res []resourceRead
+	if dc.Command == "SetPosition" {
+		res = append(res, positionRead(dc.Component), positionsRead(dc.Component))
+	}
+	if dc.Sensor != "" {
+		res = append(res, readingsRead(dc.Sensor, time.Second))
+	}
+	return res
+}
+
+// pageReads are what drawing a page and the dials needs, the page is picked the way updateKeys does
+func (c *Config) pageReads(page string) []resourceRead {
+	keys := c.Keys
+	if len(keys) == 0 {
+		var err error
+		keys, err = c.GetKeysForPage(page)
+		if err != nil || page == "" {
+			keys, _ = c.GetKeysForPage(c.InitialPage)
+		}
+	}
+
+	var res []resourceRead
+	for _, k := range keys {
+		res = append(res, k.reads()...)
+	}
+	for _, dc := range c.Dials {
+		res = append(res, dc.reads()...)
+	}
+	return res
+}
+
+// doRead reads rr from its resource in deps
+func doRead(ctx context.Context, deps resource.Dependencies, rr resourceRead) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, sensorReadTimeout)
+	defer cancel()
+
+	r, ok := vmodutils.FindDep(deps, rr.component)
+	if !ok {
+		return nil, fmt.Errorf("no resource %s", rr.component)
+	}
+
+	switch rr.kind {
+	case stateSourceReadings:
+		s, ok := r.(resource.Sensor)
+		if !ok {
+			return nil, fmt.Errorf("%s is a %T which has no readings", rr.component, r)
+		}
+		return s.Readings(ctx, nil)
+	case stateSourceDoCommand:
+		return r.DoCommand(ctx, rr.command)
+	case stateSourcePosition, readPositions:
+		sw, ok := r.(toggleswitch.Switch)
+		if !ok {
+			return nil, fmt.Errorf("%s is a %T not switch", rr.component, r)
+		}
+		if rr.kind == stateSourcePosition {
+			return sw.GetPosition(ctx, nil)
+		}
+		n, names, err := sw.GetNumberOfPositions(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return switchPositions{n: n, names: names}, nil
+	}
+	return nil, fmt.Errorf("unknown read %s", rr.kind)
+}
+
+// fetchReads does the reads that are due and returns them. It must be called without configLock.
+func (sdc *streamdeckComponent) fetchReads(ctx context.Context, deps resource.Dependencies, reads []resourceRead) []resourceRead {
+	due := map[string]resourceRead{}
+	var order []string
+	for _, rr := range reads {
+		id := rr.id()
+		if prev, ok := due[id]; ok {
+			prev.every = min(prev.every, rr.every)
+			due[id] = prev
+			continue
+		}
+		due[id] = rr
+		order = append(order, id)
+	}
+
+	var done []resourceRead
+	for _, id := range order {
+		rr := due[id]
+		sdc.readLock.Lock()
+		last, ok := sdc.reads[id]
+		sdc.readLock.Unlock()
+		if ok && time.Since(last.at) < rr.every {
+			continue
+		}
+
+		v, err := doRead(ctx, deps, rr)
+
+		sdc.readLock.Lock()
+		sdc.reads[id] = readResult{value: v, err: err, at: time.Now()}
+		sdc.readLock.Unlock()
+		done = append(done, rr)
+	}
+	return done
+}
+
+// readNow reads now, whether or not the reads are due
+func (sdc *streamdeckComponent) readNow(ctx context.Context, reads ...resourceRead) {
+	sdc.configLock.Lock()
+	deps := sdc.deps
+	sdc.configLock.Unlock()
+
+	for i := range reads {
+		reads[i].every = 0
+	}
+	sdc.fetchReads(ctx, deps, reads)
+}
+
+// lastRead is what rr got when it was last read
+func (sdc *streamdeckComponent) lastRead(rr resourceRead) (interface{}, error) {
+	sdc.readLock.Lock()
+	defer sdc.readLock.Unlock()
+
+	res, ok := sdc.reads[rr.id()]
+	if !ok {
+		return nil, fmt.Errorf("%s of %s: %w", rr.kind, rr.component, errNotRead)
+	}
+	return res.value, res.err
+}
+
+// lastPosition is where a switch was when it was last read
+func (sdc *streamdeckComponent) lastPosition(component string) (uint32, error) {
+	v, err := sdc.lastRead(positionRead(component))
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint32), nil
+}
+
+// lastPositions is how many positions a switch had and their names when it was last read
+func (sdc *streamdeckComponent) lastPositions(component string) (switchPositions, error) {
+	v, err := sdc.lastRead(positionsRead(component))
+	if err != nil {
+		return switchPositions{}, err
+	}
+	return v.(switchPositions), nil
+}
@@ -0,0 +1,249 @@
+package viamstreamdeck
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/bearsh/hid"
+	"github.com/dh1tw/streamdeck"
+	"go.uber.org/multierr"
+
+	"go.viam.com/rdk/logging"
+)
+
+// ErrDeckDisconnected is returned by a Deck once its device has been unplugged
+var ErrDeckDisconnected = errors.New("streamdeck disconnected")
+
+// deviceAttached checks if a deck with serial is still on the usb bus
+func deviceAttached(pids []uint16, serial string) bool {
+	for _, pid := range pids {
+		for _, d := range hid.Enumerate(streamdeck.VendorID, pid) {
+			if serial == "" || d.Serial == serial {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// presenceChecker is a Deck that can tell if its device is still attached without writing to it
+type presenceChecker interface {
+	attached() bool
+}
+
+// reconnectingDeck hides a deck being unplugged. While the device is gone writes are dropped,
+// and reconnect reopens it and restores the callback and brightness so the owner only has to redraw.
+type reconnectingDeck struct {
+	open   func() (Deck, error)
+	logger logging.Logger
+	onLost func() // called in the background when the device goes away
+
+	lock       sync.Mutex
+	closed     bool          // nothing is written or reopened once closed
+	deck       Deck          // nil while disconnected
+	lostClosed chan struct{} // closed once the last lost deck is
+	cb         streamdeck.BtnEvent
+	brightness *uint16
+}
+
+func newReconnectingDeck(deck Deck, open func() (Deck, error), logger logging.Logger) *reconnectingDeck {
+	return &reconnectingDeck{deck: deck, open: open, logger: logger}
+}
+
+func (rd *reconnectingDeck) do(f func(d Deck) error) error {
+	rd.lock.Lock()
+	if rd.closed || rd.deck == nil {
+		rd.lock.Unlock()
+		return nil
+	}
+
+	err := f(rd.deck)
+	if errors.Is(err, ErrDeckDisconnected) {
+		rd.lostInLock(err)
+		rd.lock.Unlock()
+		return nil
+	}
+	rd.lock.Unlock()
+	return err
+}
+
+// lostInLock forgets the deck and closes it in the background. Closing waits for the deck's event callback,
+// which may be the caller, or be waiting for locks the caller holds.
+func (rd *reconnectingDeck) lostInLock(err error) {
+	rd.logger.Warnf("lost streamdeck, waiting for it to come back: %v", err)
+	d := rd.deck
+	rd.deck = nil
+	if rd.onLost != nil {
+		// the caller may hold locks onLost needs
+		go rd.onLost()
+	}
+
+	done := make(chan struct{})
+	rd.lostClosed = done
+	go func() {
+		defer close(done)
+		if err := d.Close(); err != nil {
+			rd.logger.Debugf("error closing lost streamdeck: %v", err)
+		}
+	}()
+}
+
+// waitLostClosed waits until the last lost deck is closed, it must be called without lock
+func (rd *reconnectingDeck) waitLostClosed() {
+	rd.lock.Lock()
+	done := rd.lostClosed
+	rd.lock.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// checkAttached notices the device going away even when nothing is being written to it
+func (rd *reconnectingDeck) checkAttached() {
+	rd.lock.Lock()
+	pc, ok := rd.deck.(presenceChecker)
+	if !ok || pc.attached() {
+		rd.lock.Unlock()
+		return
+	}
+	rd.lostInLock(ErrDeckDisconnected)
+	rd.lock.Unlock()
+}
+
+func (rd *reconnectingDeck) connected() bool {
+	rd.lock.Lock()
+	defer rd.lock.Unlock()
+	return rd.deck != nil
+}
+
+// reconnect tries to reopen a lost deck, returns true if it did
+func (rd *reconnectingDeck) reconnect() (bool, error) {
+	// the device can't be opened again until the lost deck lets go of it
+	rd.waitLostClosed()
+
+	rd.lock.Lock()
+	defer rd.lock.Unlock()
+
+	if rd.closed || rd.deck != nil {
+		return false, nil
+	}
+
+	d, err := rd.open()
+	if err != nil {
+		return false, err
+	}
+
+	d.SetBtnEventCb(rd.cb)
+	if rd.brightness != nil {
+		err = d.SetBrightness(*rd.brightness)
+		if err != nil {
+			return false, multierr.Combine(err, d.Close())
+		}
+	}
+
+	rd.deck = d
+	return true, nil
+}
+
+func (rd *reconnectingDeck) WriteText(btnIndex int, textBtn streamdeck.TextButton) error {
+	return rd.do(func(d Deck) error { return d.WriteText(btnIndex, textBtn) })
+}
+
+func (rd *reconnectingDeck) WriteTextOnImage(btnIndex int, imgIn image.Image, lines []streamdeck.TextLine) error {
+	return rd.do(func(d Deck) error { return d.WriteTextOnImage(btnIndex, imgIn, lines) })
+}
+
+func (rd *reconnectingDeck) FillImage(btnIndex int, img image.Image) error {
+	return rd.do(func(d Deck) error { return d.FillImage(btnIndex, img) })
+}
+
+func (rd *reconnectingDeck) ClearBtn(btnIndex int) error {
+	return rd.do(func(d Deck) error { return d.ClearBtn(btnIndex) })
+}
+
+func (rd *reconnectingDeck) ClearAllBtns() error {
+	return rd.do(func(d Deck) error { return d.ClearAllBtns() })
+}
+
+func (rd *reconnectingDeck) FillTouchStrip(at image.Point, img image.Image) error {
+	return rd.do(func(d Deck) error { return d.FillTouchStrip(at, img) })
+}
+
+func (rd *reconnectingDeck) SetBrightness(b uint16) error {
+	rd.lock.Lock()
+	rd.brightness = &b
+	rd.lock.Unlock()
+
+	return rd.do(func(d Deck) error { return d.SetBrightness(b) })
+}
+
+func (rd *reconnectingDeck) SetBtnEventCb(ev streamdeck.BtnEvent) {
+	rd.lock.Lock()
+	defer rd.lock.Unlock()
+	rd.cb = ev
+	if rd.deck != nil {
+		rd.deck.SetBtnEventCb(ev)
+	}
+}
+
+func (rd *reconnectingDeck) Close() error {
+	rd.lock.Lock()
+	rd.closed = true
+	d := rd.deck
+	rd.deck = nil
+	rd.lock.Unlock()
+
+	rd.waitLostClosed()
+	if d == nil {
+		return nil
+	}
+	return d.Close()
+}
+
+// libraryDeck adds disconnect detection to the streamdeck library
+type libraryDeck struct {
+	*streamdeck.StreamDeck
+	pids   []uint16
+	serial string
+}
+
+func (ld *libraryDeck) attached() bool {
+	return deviceAttached(ld.pids, ld.serial)
+}
+
+func (ld *libraryDeck) check(err error) error {
+	if err != nil && !deviceAttached(ld.pids, ld.serial) {
+		return fmt.Errorf("%w: %w", ErrDeckDisconnected, err)
+	}
+	return err
+}
+
+func (ld *libraryDeck) WriteText(btnIndex int, textBtn streamdeck.TextButton) error {
+	return ld.check(ld.StreamDeck.WriteText(btnIndex, textBtn))
+}
+
+func (ld *libraryDeck) WriteTextOnImage(btnIndex int, imgIn image.Image, lines []streamdeck.TextLine) error {
+	return ld.check(ld.StreamDeck.WriteTextOnImage(btnIndex, imgIn, lines))
+}
+
+func (ld *libraryDeck) FillImage(btnIndex int, img image.Image) error {
+	return ld.check(ld.StreamDeck.FillImage(btnIndex, img))
+}
+
+func (ld *libraryDeck) ClearBtn(btnIndex int) error {
+	return ld.check(ld.StreamDeck.ClearBtn(btnIndex))
+}
+
+func (ld *libraryDeck) ClearAllBtns() error {
+	return ld.check(ld.StreamDeck.ClearAllBtns())
+}
+
+func (ld *libraryDeck) FillTouchStrip(at image.Point, img image.Image) error {
+	return errNoTouchStrip
+}
+
+func (ld *libraryDeck) SetBrightness(b uint16) error {
+	return ld.check(ld.StreamDeck.SetBrightness(b))
+}
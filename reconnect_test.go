@@ -0,0 +1,142 @@
+package viamstreamdeck
+
+import (
+	"errors"
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+// lostDeck fails writes as unplugged, and like a usb deck its Close waits for a callback that is running
+type lostDeck struct {
+	*FakeDeck
+	rd *reconnectingDeck
+}
+
+func (ld *lostDeck) FillImage(btnIndex int, img image.Image) error {
+	return ErrDeckDisconnected
+}
+
+func (ld *lostDeck) Close() error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = ld.rd.ClearBtn(0)
+	}()
+	<-done
+	return ld.FakeDeck.Close()
+}
+
+func TestReconnectingDeckLost(t *testing.T) {
+	ld := &lostDeck{FakeDeck: NewFakeDeck(streamdeck.Plus)}
+	rd := newReconnectingDeck(ld, func() (Deck, error) { return ld.FakeDeck, nil }, logging.NewTestLogger(t))
+	ld.rd = rd
+
+	done := make(chan error)
+	go func() { done <- rd.FillImage(0, image.Black) }()
+	select {
+	case err := <-done:
+		test.That(t, err, test.ShouldBeNil)
+	case <-time.After(5 * time.Second):
+		t.Fatal("losing the deck deadlocked")
+	}
+	test.That(t, rd.connected(), test.ShouldBeFalse)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, ld.Closed(), test.ShouldBeTrue)
+	})
+}
+
+func TestReconnectingDeckClosed(t *testing.T) {
+	fd := NewFakeDeck(streamdeck.Plus)
+	opened := 0
+	rd := newReconnectingDeck(fd, func() (Deck, error) {
+		opened++
+		return fd, nil
+	}, logging.NewTestLogger(t))
+
+	test.That(t, rd.Close(), test.ShouldBeNil)
+	test.That(t, fd.Closed(), test.ShouldBeTrue)
+
+	// a reconnect racing with Close doesn't open the device again
+	reconnected, err := rd.reconnect()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, reconnected, test.ShouldBeFalse)
+	test.That(t, opened, test.ShouldEqual, 0)
+	test.That(t, rd.FillImage(0, image.White), test.ShouldBeNil)
+}
+
+// unpluggedHID is a hid device that sends its reports, then fails every write as if it was unplugged
+type unpluggedHID struct {
+	lock    sync.Mutex
+	reports [][]byte
+	closed  bool
+}
+
+func (u *unpluggedHID) Write(b []byte) (int, error) {
+	return 0, errors.New("device gone")
+}
+
+func (u *unpluggedHID) SendFeatureReport(b []byte) (int, error) {
+	return 0, errors.New("device gone")
+}
+
+func (u *unpluggedHID) ReadTimeout(b []byte, timeout int) (int, error) {
+	u.lock.Lock()
+	if len(u.reports) > 0 {
+		n := copy(b, u.reports[0])
+		u.reports = u.reports[1:]
+		u.lock.Unlock()
+		return n, nil
+	}
+	u.lock.Unlock()
+	time.Sleep(time.Duration(timeout) * time.Millisecond)
+	return 0, nil
+}
+
+func (u *unpluggedHID) Close() error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.closed = true
+	return nil
+}
+
+func (u *unpluggedHID) isClosed() bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return u.closed
+}
+
+func TestUSBDeckLostInCallback(t *testing.T) {
+	// a key press, then writes fail and no deck with this product id is attached
+	dev := &unpluggedHID{reports: [][]byte{{0x01, 0x00, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}}}
+	d := &usbDeck{
+		conf: streamdeck.Plus, proto: usbProtocolV2, device: dev, pids: []uint16{0xffff},
+		numDials: 4, touchWidth: 800, events: make(chan usbEvent, 64),
+	}
+	rd := newReconnectingDeck(d, func() (Deck, error) { return nil, errors.New("still gone") }, logging.NewTestLogger(t))
+
+	returned := make(chan error, 1)
+	rd.SetBtnEventCb(func(s streamdeck.State, e streamdeck.Event) {
+		// the deck is lost while drawing from its own callback
+		returned <- rd.FillImage(0, newButtonImage(streamdeck.Plus.ButtonSize, image.Black))
+	})
+	d.start()
+
+	select {
+	case err := <-returned:
+		test.That(t, err, test.ShouldBeNil)
+	case <-time.After(5 * time.Second):
+		t.Fatal("losing the deck from its callback deadlocked")
+	}
+	test.That(t, rd.connected(), test.ShouldBeFalse)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, dev.isClosed(), test.ShouldBeTrue)
+	})
+}
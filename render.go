@@ -0,0 +1,70 @@
+package viamstreamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/dh1tw/streamdeck"
+	"github.com/disintegration/gift"
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+
+	"golang.org/x/image/font"
+)
+
+// newButtonImage returns a size x size image filled with bg
+func newButtonImage(size int, bg image.Image) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), bg, bg.Bounds().Min, draw.Src)
+	return img
+}
+
+// resizeImage scales img the same way the streamdeck library does before sending it to a key
+func resizeImage(img image.Image, width, height int) *image.RGBA {
+	g := gift.New(
+		gift.Resize(width, height, gift.LanczosResampling),
+		gift.UnsharpMask(1, 1, 0),
+	)
+	res := image.NewRGBA(g.Bounds(image.Rect(0, 0, width, height)))
+	g.Draw(res, img)
+	return res
+}
+
+// drawTextLines renders lines onto img matching streamdeck.WriteTextOnImage
+func drawTextLines(img *image.RGBA, lines []streamdeck.TextLine) error {
+	for _, line := range lines {
+		if line.Font == nil {
+			line.Font = streamdeck.MonoRegular
+		}
+		c := freetype.NewContext()
+		c.SetDPI(72)
+		c.SetFont(line.Font)
+		c.SetFontSize(line.FontSize)
+		c.SetClip(img.Bounds())
+		c.SetDst(img)
+		c.SetSrc(image.NewUniform(line.FontColor))
+		pt := freetype.Pt(line.PosX, line.PosY+int(c.PointToFixed(textBaseline)>>6))
+
+		if _, err := c.DrawString(line.Text, pt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drawCenteredText draws s across the middle of img with its baseline at y
+func drawCenteredText(img *image.RGBA, s string, size float64, y int, clr color.Color) error {
+	f := streamdeck.MonoRegular
+	w := font.MeasureString(truetype.NewFace(f, &truetype.Options{Size: size, DPI: 72}), s).Ceil()
+
+	c := freetype.NewContext()
+	c.SetDPI(72)
+	c.SetFont(f)
+	c.SetFontSize(size)
+	c.SetClip(img.Bounds())
+	c.SetDst(img)
+	c.SetSrc(image.NewUniform(clr))
+	_, err := c.DrawString(s, freetype.Pt(img.Bounds().Min.X+(img.Bounds().Dx()-w)/2, y))
+	return err
+}
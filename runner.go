@@ -0,0 +1,167 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// what to do when a key is pressed while its last action is still running
+const (
+	policyQueue          = "queue"           // run it after the one running, the default
+	policyDrop           = "drop"            // ignore the press
+	policyCancelPrevious = "cancel_previous" // cancel the one running and start now
+
+	// for on_release, queue it and run it even when closing so whatever on_press started is stopped
+	policyAlways = "always"
+)
+
+var policies = []string{policyQueue, policyDrop, policyCancelPrevious}
+
+func (kc *KeyConfig) policy() string {
+	return orQueue(kc.Policy)
+}
+
+func orQueue(policy string) string {
+	if policy == "" {
+		return policyQueue
+	}
+	return policy
+}
+
+// keyRun is an action running for a key
+type keyRun struct {
+	cancel context.CancelFunc
+}
+
+// queuedRun is an action waiting for the one before it on the same input
+type queuedRun struct {
+	timeoutMs int
+	f         func(ctx context.Context)
+	always    bool
+}
+
+// actionQueue is the actions of a key, dial or the touch strip, the one running and the ones waiting for it
+type actionQueue struct {
+	run    *keyRun // the action running now
+	queued []queuedRun
+}
+
+// actionContext is cancelled when the component closes, or after timeoutMs if it is set
+func (sdc *streamdeckComponent) actionContext(timeoutMs int) (context.Context, context.CancelFunc) {
+	if timeoutMs > 0 {
+		return context.WithTimeout(sdc.closeCtx, time.Duration(timeoutMs)*time.Millisecond)
+	}
+	return context.WithCancel(sdc.closeCtx)
+}
+
+// runKeyAction runs f for a key on a worker, so a slow action doesn't hold up other events.
+// If the key's last action is still running, policy says what happens.
+func (sdc *streamdeckComponent) runKeyAction(k KeyConfig, policy string, f func(ctx context.Context)) {
+	sdc.pressLock.Lock()
+	defer sdc.pressLock.Unlock()
+	sdc.runQueued(&sdc.keyPressState(k.Key).actionQueue, fmt.Sprintf("key %d", k.Key), k.TimeoutMs, policy, f)
+}
+
+// runInputAction is runKeyAction for dials and the touch strip, input names which one
+func (sdc *streamdeckComponent) runInputAction(input string, timeoutMs int, policy string, f func(ctx context.Context)) {
+	sdc.pressLock.Lock()
+	defer sdc.pressLock.Unlock()
+
+	q, ok := sdc.inputs[input]
+	if !ok {
+		q = &actionQueue{}
+		sdc.inputs[input] = q
+	}
+	sdc.runQueued(q, input, timeoutMs, policy, f)
+}
+
+// runQueued must be called with pressLock held
+func (sdc *streamdeckComponent) runQueued(q *actionQueue, name string, timeoutMs int, policy string, f func(ctx context.Context)) {
+	if sdc.closed.Load() != 0 {
+		if policy == policyAlways {
+			sdc.pressLock.Unlock()
+			f(context.Background())
+			sdc.pressLock.Lock()
+		}
+		return
+	}
+
+	if q.run != nil {
+		switch policy {
+		case policyDrop:
+			sdc.logger.Infof("%s is still running, dropping event", name)
+			return
+		case policyCancelPrevious:
+			q.run.cancel()
+			q.queued = nil
+		default:
+			q.queued = append(q.queued, queuedRun{timeoutMs: timeoutMs, f: f, always: policy == policyAlways})
+			return
+		}
+	}
+
+	sdc.startRun(q, timeoutMs, f)
+}
+
+// startRun must be called with pressLock held
+func (sdc *streamdeckComponent) startRun(q *actionQueue, timeoutMs int, f func(ctx context.Context)) {
+	ctx, cancel := sdc.actionContext(timeoutMs)
+	run := &keyRun{cancel: cancel}
+	q.run = run
+
+	sdc.workers.Add(1)
+	go func() {
+		defer sdc.workers.Done()
+		f(ctx)
+		cancel()
+
+		sdc.pressLock.Lock()
+		defer sdc.pressLock.Unlock()
+		if q.run != run {
+			// cancelled and replaced
+			return
+		}
+		q.run = nil
+		if len(q.queued) > 0 && sdc.closed.Load() == 0 {
+			next := q.queued[0]
+			q.queued = q.queued[1:]
+			sdc.startRun(q, next.timeoutMs, next.f)
+		}
+	}()
+}
+
+// runLeftovers is for once closed and the workers are done, it runs queued actions that have to happen
+func (sdc *streamdeckComponent) runLeftovers() {
+	sdc.pressLock.Lock()
+	queues := []*actionQueue{}
+	for _, kp := range sdc.presses {
+		queues = append(queues, &kp.actionQueue)
+	}
+	for _, q := range sdc.inputs {
+		queues = append(queues, q)
+	}
+
+	left := []queuedRun{}
+	for _, q := range queues {
+		for _, r := range q.queued {
+			if r.always {
+				left = append(left, r)
+			}
+		}
+		q.queued = nil
+	}
+	sdc.pressLock.Unlock()
+
+	for _, r := range left {
+		r.f(context.Background())
+	}
+}
+
+func checkPolicy(p string) error {
+	if p != "" && !slices.Contains(policies, p) {
+		return fmt.Errorf("unknown policy %s, can be %v", p, policies)
+	}
+	return nil
+}
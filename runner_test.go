@@ -0,0 +1,162 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/generic"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+// slowThing's DoCommand doesn't return until it is let go or cancelled
+type slowThing struct {
+	testThing
+
+	release   chan struct{}
+	lock      sync.Mutex
+	cancelled int
+}
+
+func (st *slowThing) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	_, err := st.testThing.DoCommand(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-st.release:
+		return cmd, nil
+	case <-ctx.Done():
+		st.lock.Lock()
+		st.cancelled++
+		st.lock.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (st *slowThing) numCancelled() int {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	return st.cancelled
+}
+
+func TestKeyPolicies(t *testing.T) {
+	key := func(n int, policy string) KeyConfig {
+		return KeyConfig{Key: n, Text: "k" + policy, Component: "slow", Method: "do_command", Args: []interface{}{map[string]interface{}{"key": float64(n)}}, Policy: policy}
+	}
+	timeout := key(3, "")
+	timeout.TimeoutMs = 20
+
+	conf := &Config{Keys: []KeyConfig{key(0, "drop"), key(1, ""), key(2, "cancel_previous"), timeout}}
+
+	st := &slowThing{testThing: testThing{name: generic.Named("slow")}, release: make(chan struct{})}
+	fd := NewFakeDeck(streamdeck.Plus)
+	r, err := NewStreamDeck(context.Background(), generic.Named("deck"), resource.Dependencies{st.name: st}, fd.Model(), conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	sdc := r.(*streamdeckComponent)
+
+	started := func(n int) func(tb testing.TB) {
+		return func(tb testing.TB) {
+			test.That(tb, st.commands(), test.ShouldHaveLength, n)
+		}
+	}
+
+	// a slow action doesn't hold up other keys, and drop ignores presses while running
+	fd.ClickKey(0)
+	fd.ClickKey(0)
+	testutils.WaitForAssertion(t, started(1))
+	st.release <- struct{}{}
+	waitForActions(sdc)
+	test.That(t, st.commands(), test.ShouldHaveLength, 1)
+
+	// queue runs them one after the other
+	fd.ClickKey(1)
+	fd.ClickKey(1)
+	testutils.WaitForAssertion(t, started(2))
+	st.release <- struct{}{}
+	testutils.WaitForAssertion(t, started(3))
+	st.release <- struct{}{}
+	waitForActions(sdc)
+
+	// cancel_previous stops the running one
+	fd.ClickKey(2)
+	testutils.WaitForAssertion(t, started(4))
+	fd.ClickKey(2)
+	testutils.WaitForAssertion(t, started(5))
+	test.That(t, st.numCancelled(), test.ShouldEqual, 1)
+	st.release <- struct{}{}
+	waitForActions(sdc)
+
+	fd.ClickKey(3)
+	waitForActions(sdc)
+	test.That(t, st.numCancelled(), test.ShouldEqual, 2)
+
+	// closing cancels what is still running
+	fd.ClickKey(1)
+	testutils.WaitForAssertion(t, started(7))
+	start := time.Now()
+	test.That(t, r.Close(context.Background()), test.ShouldBeNil)
+	test.That(t, time.Since(start), test.ShouldBeLessThan, time.Second)
+	test.That(t, st.numCancelled(), test.ShouldEqual, 3)
+
+	bad := &Config{Keys: []KeyConfig{key(0, "sometimes")}}
+	_, _, err = bad.Validate("")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestDialAndTouchWorkers(t *testing.T) {
+	conf := &Config{
+		Keys:  []KeyConfig{{Key: 0, Text: "k", Component: "slow", Method: "do_command", Args: []interface{}{map[string]interface{}{"key": 0.0}}}},
+		Dials: []DialConfig{{Dial: 0, Component: "slow", Command: "DoCommand", TimeoutMs: 20}},
+		Touch: &TouchConfig{
+			Segments: []TouchSegmentConfig{{Segment: 1, Tap: &ActionConfig{Component: "slow", Method: "do_command"}}},
+			Policy:   "drop",
+		},
+	}
+
+	st := &slowThing{testThing: testThing{name: generic.Named("slow")}, release: make(chan struct{})}
+	fd := NewFakeDeck(streamdeck.Plus)
+	r, err := NewStreamDeck(context.Background(), generic.Named("deck"), resource.Dependencies{st.name: st}, fd.Model(), conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	sdc := r.(*streamdeckComponent)
+
+	started := func(n int) func(tb testing.TB) {
+		return func(tb testing.TB) {
+			test.That(tb, st.commands(), test.ShouldHaveLength, n)
+		}
+	}
+
+	// a slow tap doesn't hold up keys, and the touch strip's policy drops taps while it runs
+	fd.Touch(EventTouchTap, 1)
+	fd.Touch(EventTouchTap, 1)
+	fd.ClickKey(0)
+	testutils.WaitForAssertion(t, started(2))
+	st.release <- struct{}{}
+	st.release <- struct{}{}
+	waitForActions(sdc)
+	test.That(t, st.commands(), test.ShouldHaveLength, 2)
+
+	// the dial's turns time out
+	fd.TurnDial(0, 1)
+	waitForActions(sdc)
+	test.That(t, st.numCancelled(), test.ShouldEqual, 1)
+
+	// closing cancels a turn still running
+	sdc.configLock.Lock()
+	sdc.conf.Dials[0].TimeoutMs = 0
+	sdc.configLock.Unlock()
+	fd.TurnDial(0, 1)
+	testutils.WaitForAssertion(t, started(4))
+	test.That(t, r.Close(context.Background()), test.ShouldBeNil)
+	test.That(t, st.numCancelled(), test.ShouldEqual, 2)
+
+	bad := &Config{Dials: []DialConfig{{Dial: 0, Component: "slow", Command: "DoCommand", Policy: "sometimes"}}}
+	_, _, err = bad.Validate("")
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,110 @@
+package viamstreamdeck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+const sensorReadTimeout = time.Second
+
+func parseKeyTemplate(s string) (*template.Template, error) {
+	return template.New("key").Option("missingkey=error").Parse(s)
+}
+
+func (kc *KeyConfig) refreshInterval() time.Duration {
+	if kc.RefreshSecs <= 0 {
+		return time.Second
+	}
+	return time.Duration(kc.RefreshSecs * float64(time.Second))
+}
+
+// renderTemplate fills in a template with a sensor's readings
+func renderTemplate(tmpl string, readings interface{}) (string, error) {
+	t, err := parseKeyTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	err = t.Execute(&buf, readings)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sensorText fills in a template with the readings of a sensor from when it was last read, "?" if it can't
+func (sdc *streamdeckComponent) sensorText(id, sensor, tmpl string) string {
+	readings, err := sdc.lastRead(readingsRead(sensor, 0))
+	text := ""
+	if err == nil {
+		text, err = renderTemplate(tmpl, readings)
+	}
+	if err != nil {
+		sdc.logger.Warnf("can't render %s from %s: %v", id, sensor, err)
+		return "?"
+	}
+	return text
+}
+
+// sensorKeyText returns the text for a key with a sensor
+func (sdc *streamdeckComponent) sensorKeyText(k KeyConfig) string {
+	return sdc.sensorText(keySensorID(k.Key), k.Sensor, k.Template)
+}
+
+func keySensorID(key int) string {
+	return fmt.Sprintf("key %d", key)
+}
+
+// sensorRefresher reads sensors as their refresh comes due and redraws what shows them, between the once a second full redraws
+func (sdc *streamdeckComponent) sensorRefresher() {
+	for sdc.closed.Load() == 0 {
+		sdc.refreshSensors(context.Background())
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (sdc *streamdeckComponent) refreshSensors(ctx context.Context) {
+	sdc.configLock.Lock()
+	deps := sdc.deps
+	var timed []resourceRead
+	for _, rr := range sdc.conf.pageReads(sdc.currentPage) {
+		if rr.every > 0 {
+			timed = append(timed, rr)
+		}
+	}
+	sdc.configLock.Unlock()
+
+	done := map[string]bool{}
+	for _, rr := range sdc.fetchReads(ctx, deps, timed) {
+		done[rr.component] = true
+	}
+	if len(done) == 0 {
+		return
+	}
+
+	sdc.configLock.Lock()
+	defer sdc.configLock.Unlock()
+
+	for _, k := range sdc.keys {
+		if k.Sensor == "" || !done[k.Sensor] {
+			continue
+		}
+		err := sdc.updateKey(ctx, k)
+		if err != nil {
+			sdc.logger.Warnf("can't refresh key %d: %v", k.Key, err)
+		}
+	}
+	for _, dc := range sdc.conf.Dials {
+		if dc.Sensor != "" && done[dc.Sensor] {
+			err := sdc.updateTouchStrip(ctx)
+			if err != nil {
+				sdc.logger.Warnf("can't refresh touch strip: %v", err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/generic"
+	"go.viam.com/test"
+)
+
+type testSensor struct {
+	testThing
+
+	lock     sync.Mutex
+	readings map[string]interface{}
+}
+
+func (ts *testSensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	return maps.Clone(ts.readings), nil
+}
+
+func (ts *testSensor) set(k string, v interface{}) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	ts.readings[k] = v
+}
+
+func TestSensorTemplate(t *testing.T) {
+	conf := &Config{
+		Keys: []KeyConfig{
+			{Key: 0, Sensor: "temp", Template: `{{.temperature | printf "%.1f"}} C`, RefreshSecs: .01},
+			{Key: 1, Sensor: "temp", Template: `{{.nope}}`},
+		},
+	}
+	_, deps, err := conf.Validate("")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"temp"})
+
+	ts := &testSensor{testThing: testThing{name: generic.Named("temp")}, readings: map[string]interface{}{"temperature": 21.04}}
+
+	fd := NewFakeDeck(streamdeck.Plus)
+	r, err := NewStreamDeck(context.Background(), generic.Named("deck"), resource.Dependencies{ts.name: ts}, fd.Model(), conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer r.Close(context.Background())
+	sdc := r.(*streamdeckComponent)
+
+	test.That(t, sdc.sensorKeyText(conf.Keys[0]), test.ShouldEqual, "21.0 C")
+	test.That(t, sdc.sensorKeyText(conf.Keys[1]), test.ShouldEqual, "?")
+	writes := fd.Writes(0)
+
+	ts.set("temperature", 30.0)
+	time.Sleep(20 * time.Millisecond)
+	sdc.refreshSensors(context.Background())
+
+	test.That(t, sdc.sensorKeyText(conf.Keys[0]), test.ShouldEqual, "30.0 C")
+	test.That(t, fd.Writes(0), test.ShouldBeGreaterThan, writes)
+
+	// pressing a key that only shows a sensor does nothing
+	fd.ClickKey(0)
+
+	bad := &Config{Keys: []KeyConfig{{Key: 0, Sensor: "temp", Template: "{{.x"}}}
+	_, _, err = bad.Validate("")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+// slowSensor takes delay to read
+type slowSensor struct {
+	testSensor
+	delay time.Duration
+}
+
+func (ss *slowSensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	ss.lock.Lock()
+	delay := ss.delay
+	ss.lock.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(delay):
+	}
+	return ss.testSensor.Readings(ctx, extra)
+}
+
+func TestSlowSensor(t *testing.T) {
+	conf := &Config{
+		Keys: []KeyConfig{
+			{Key: 0, Sensor: "temp", Template: `{{.temperature}}`, RefreshSecs: .01},
+		},
+	}
+	ss := &slowSensor{testSensor: testSensor{testThing: testThing{name: generic.Named("temp")}, readings: map[string]interface{}{"temperature": 21}}}
+
+	fd := NewFakeDeck(streamdeck.Plus)
+	r, err := NewStreamDeck(context.Background(), generic.Named("deck"), resource.Dependencies{ss.name: ss}, fd.Model(), conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer r.Close(context.Background())
+	sdc := r.(*streamdeckComponent)
+
+	ss.lock.Lock()
+	ss.delay = 500 * time.Millisecond
+	ss.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		sdc.refreshSensors(context.Background())
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// the config isn't held while the sensor is read
+	start := time.Now()
+	_, err = sdc.getKeyConfig(0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, time.Since(start), test.ShouldBeLessThan, 100*time.Millisecond)
+	<-done
+}
@@ -3,6 +3,8 @@ package viamstreamdeck
 import (
 	"context"
 	"fmt"
+	"math"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -42,11 +44,15 @@ func NewStreamDeck(ctx context.Context, name resource.Name, deps resource.Depend
 	}
 
 	if ms == nil {
-		ms = FindAttachedStreamDeck()
-		if ms == nil {
-			return nil, fmt.Errorf("no streamdeck found")
+		ms, err = pickStreamDeck(ListAttachedStreamDecks(), conf.Serial)
+		if err != nil {
+			return nil, err
 		}
 	}
+	err = conf.checkModel(ms)
+	if err != nil {
+		return nil, err
+	}
 
 	sdc := &streamdeckComponent{
 		name:   name,
@@ -55,23 +61,46 @@ func NewStreamDeck(ctx context.Context, name resource.Name, deps resource.Depend
 		conf:   conf,
 		deps:   deps,
 		keys:   map[int]KeyConfig{},
+		theme:  conf.Theme,
+
+		reads:     map[string]readResult{},
+		dials:     map[int]*dialState{},
+		keyStates: map[string]int{},
+
+		keyFlashes:     map[int]flash{},
+		segmentFlashes: map[int]flash{},
+		presses:        map[int]*keyPress{},
+		inputs:         map[string]*actionQueue{},
 	}
+	sdc.closeCtx, sdc.closeCancel = context.WithCancel(context.Background())
 
-	sdc.sd, err = streamdeck.NewStreamDeckWithConfig(&ms.Conf, "")
+	d, err := ms.open(conf)
 	if err != nil && ms == ModelOriginal {
 		// original vs original2 is confusing, try it
 		ms = ModelOriginal2
 		sdc.ms = ModelOriginal2
-		sdc.sd, err = streamdeck.NewStreamDeckWithConfig(&ms.Conf, "")
+		d, err = ms.open(conf)
 	}
 
 	if err != nil {
+		sdc.closeCancel()
 		return nil, err
 	}
 
+	sdc.sd = newReconnectingDeck(d, func() (Deck, error) { return sdc.ms.open(sdc.conf) }, logger)
+	sdc.sd.onLost = func() {
+		sdc.releaseHeldKeys()
+		sdc.resetDials()
+	}
+	// once the deck is open, nothing can be left behind if setting it up fails
+	fail := func(err error) (resource.Resource, error) {
+		sdc.closeCancel()
+		return nil, multierr.Combine(err, sdc.sd.Close())
+	}
+
 	err = sdc.updateBrightness(conf.Brightness)
 	if err != nil {
-		return nil, err
+		return fail(err)
 	}
 
 	// Initialize with appropriate keys
@@ -80,20 +109,28 @@ func NewStreamDeck(ctx context.Context, name resource.Name, deps resource.Depend
 		logger.Debugf("Initializing with page: %s", sdc.currentPage)
 	}
 
+	sdc.fetchReads(ctx, deps, conf.pageReads(sdc.currentPage))
+
 	err = sdc.updateKeys(ctx)
 	if err != nil {
-		return nil, err
+		return fail(err)
+	}
+
+	err = sdc.updateTouchStrip(ctx)
+	if err != nil {
+		return fail(err)
 	}
 
 	sdc.sd.SetBtnEventCb(func(s streamdeck.State, e streamdeck.Event) {
 		logger.Infof("got event %v", e)
-		err := sdc.HandleEvent(context.Background(), s, e)
+		err := sdc.HandleEvent(sdc.closeCtx, s, e)
 		if err != nil {
 			logger.Errorf("event handler failed for event %v: %v", e, err)
 		}
 	})
 
 	go sdc.stateChecker()
+	go sdc.sensorRefresher()
 
 	return sdc, nil
 }
@@ -104,13 +141,31 @@ func (sdc *streamdeckComponent) Reconfigure(ctx context.Context, deps resource.D
 		return err
 	}
 
+	if newConf.Serial != sdc.conf.Serial || !reflect.DeepEqual(newConf.Virtual, sdc.conf.Virtual) {
+		// the device is only picked at construction
+		return resource.NewMustRebuildError(conf.ResourceName())
+	}
+	err = newConf.checkModel(sdc.ms)
+	if err != nil {
+		return err
+	}
+
 	return sdc.reconfigure(ctx, deps, newConf)
 }
 
 func (sdc *streamdeckComponent) reconfigure(ctx context.Context, deps resource.Dependencies, newConf *Config) error {
+	// read what drawing needs first, resources can be slow and nothing waits on configLock meanwhile
+	sdc.configLock.Lock()
+	reads := newConf.pageReads(sdc.currentPage)
+	sdc.configLock.Unlock()
+	sdc.fetchReads(ctx, deps, reads)
+
 	sdc.configLock.Lock()
 	defer sdc.configLock.Unlock()
 
+	if _, ok := newConf.Themes[sdc.theme]; !ok || newConf.Theme != sdc.conf.Theme {
+		sdc.theme = newConf.Theme
+	}
 	sdc.deps = deps
 	sdc.conf = newConf
 
@@ -119,7 +174,12 @@ func (sdc *streamdeckComponent) reconfigure(ctx context.Context, deps resource.D
 		return err
 	}
 
-	return sdc.updateKeys(ctx)
+	err = sdc.updateKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	return sdc.updateTouchStrip(ctx)
 }
 
 type streamdeckComponent struct {
@@ -127,7 +187,7 @@ type streamdeckComponent struct {
 	logger logging.Logger
 	ms     *ModelSetup
 
-	sd *streamdeck.StreamDeck
+	sd *reconnectingDeck
 
 	configLock sync.Mutex
 	deps       resource.Dependencies
@@ -135,6 +195,25 @@ type streamdeckComponent struct {
 	keys       map[int]KeyConfig
 
 	currentPage string
+	theme       string // the deck's theme, from the config or set_theme
+
+	dials     map[int]*dialState // dials that have been turned or shown
+	keyStates map[string]int     // by keyStateID, which state keys with states have been moved to
+
+	readLock sync.Mutex
+	reads    map[string]readResult // by resourceRead id, what drawing last read, without configLock
+
+	keyFlashes     map[int]flash // by key, results being shown
+	segmentFlashes map[int]flash // by touch strip segment
+
+	pressLock sync.Mutex
+	presses   map[int]*keyPress
+	inputs    map[string]*actionQueue // actions of dials and the touch strip
+
+	// for work that outlives an event, like macros
+	closeCtx    context.Context
+	closeCancel context.CancelFunc
+	workers     sync.WaitGroup
 
 	closed atomic.Int32
 }
@@ -171,9 +250,50 @@ func (sdc *streamdeckComponent) applyKeyUpdate(existing KeyConfig, updates map[s
 	if method, ok := updates["method"].(string); ok {
 		result.Method = method
 	}
+	if sensor, ok := updates["sensor"].(string); ok {
+		result.Sensor = sensor
+	}
+	if tmpl, ok := updates["template"].(string); ok {
+		if _, err := parseKeyTemplate(tmpl); err != nil {
+			return result, fmt.Errorf("bad template: %w", err)
+		}
+		result.Template = tmpl
+	}
 	if args, ok := updates["args"].([]interface{}); ok {
 		result.Args = args
 	}
+	if badge, ok := updates["badge"]; ok {
+		// null removes the badge, a string is its text
+		switch b := badge.(type) {
+		case nil:
+			result.Badge = nil
+		case string:
+			result.Badge = &BadgeConfig{Text: b}
+		default:
+			var bc BadgeConfig
+			d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{TagName: "json", Result: &bc})
+			if err != nil {
+				return result, err
+			}
+			if err := d.Decode(b); err != nil {
+				return result, fmt.Errorf("bad badge: %w", err)
+			}
+			if err := bc.Validate(); err != nil {
+				return result, fmt.Errorf("bad badge: %w", err)
+			}
+			result.Badge = &bc
+		}
+	}
+
+	if result.Component != "" {
+		if err := result.action().Validate(); err != nil {
+			return result, err
+		}
+	}
+
+	if err := sdc.conf.checkKeyColors(result, sdc.conf.keyThemeName(result, sdc.currentPage, sdc.theme)); err != nil {
+		return result, err
+	}
 
 	return result, nil
 }
@@ -188,6 +308,43 @@ func (sdc *streamdeckComponent) applyDialUpdate(existing DialConfig, updates map
 	if command, ok := updates["command"].(string); ok {
 		result.Command = command
 	}
+	if label, ok := updates["label"].(string); ok {
+		result.Label = label
+	}
+	if sensor, ok := updates["sensor"].(string); ok {
+		result.Sensor = sensor
+	}
+	if tmpl, ok := updates["template"].(string); ok {
+		result.Template = tmpl
+	}
+	if bar, ok := updates["bar"].(bool); ok {
+		result.Bar = bar
+	}
+	for name, field := range map[string]**float64{"min": &result.Min, "max": &result.Max, "initial": &result.Initial} {
+		if v, ok := updates[name].(float64); ok {
+			*field = &v
+		}
+	}
+	for name, field := range map[string]*float64{"step": &result.Step, "scale": &result.Scale, "offset": &result.Offset} {
+		if v, ok := updates[name].(float64); ok {
+			*field = v
+		}
+	}
+	if wrap, ok := updates["wrap"].(bool); ok {
+		result.Wrap = wrap
+	}
+	if payload, ok := updates["payload"].(map[string]interface{}); ok {
+		result.Payload = payload
+		if _, err := result.payload(dialTurn{}); err != nil {
+			return existing, fmt.Errorf("bad payload: %w", err)
+		}
+	}
+
+	if result.Sensor != "" {
+		if _, err := parseKeyTemplate(result.Template); err != nil {
+			return existing, fmt.Errorf("bad template: %w", err)
+		}
+	}
 
 	return result, nil
 }
@@ -197,8 +354,27 @@ func (sdc *streamdeckComponent) isSelfReference(componentName string) bool {
 }
 
 func (sdc *streamdeckComponent) updateKey(ctx context.Context, k KeyConfig) error {
+	if k.Confirm && sdc.confirming(k.Key) {
+		return sdc.drawConfirm(k)
+	}
+	if f, ok := activeFlash(sdc.keyFlashes, k.Key); ok {
+		return sdc.drawKeyFlash(k, f)
+	}
+
+	if len(k.States) > 0 {
+		i, err := sdc.keyStateIndex(k)
+		if err != nil {
+			return err
+		}
+		k = k.withState(i)
+	}
+
+	if k.Sensor != "" {
+		k.Text = sdc.sensorKeyText(k)
+	}
+
 	_, ok := vmodutils.FindDep(sdc.deps, k.Component)
-	if !ok && !sdc.isSelfReference(k.Component) {
+	if !ok && !sdc.isSelfReference(k.Component) && k.Component != "" {
 		sdc.logger.Warnf("missing component %v deps: %v", k.Component, sdc.deps)
 
 		img, ok := assetImages["x.jpg"]
@@ -213,34 +389,20 @@ func (sdc *streamdeckComponent) updateKey(ctx context.Context, k KeyConfig) erro
 		)
 	}
 
-	if snakeToCamel(k.Method) != "DoCommand" && snakeToCamel(k.Method) != "SetPosition" {
-		return fmt.Errorf("only support DoCommand and SetPosition now, not %s", k.Method)
-	}
-
-	if k.Image != "" {
-		img, ok := assetImages[k.Image]
-		if ok {
-			if k.Text != "" {
-				return sdc.sd.WriteTextOnImage(
-					k.Key,
-					img,
-					sdc.ms.SimpleText(k.Text, k.TextColor, k.TextFont),
-				)
-			}
-			return sdc.sd.FillImage(k.Key, img)
+	if k.Component != "" {
+		if _, err := findKeyAction(k.Method); err != nil {
+			return err
 		}
-		return fmt.Errorf("unknown image [%s]", k.Image)
 	}
 
-	if k.Text == "" && snakeToCamel(k.Method) == "SetPosition" {
-		s, err := sdc.findSwitch(ctx, k.Component)
-		if err != nil {
-			return err
-		}
-		_, names, err := s.GetNumberOfPositions(ctx, nil)
+	k = sdc.applyTheme(sdc.applyStyles(k))
+
+	if k.Image == "" && k.Text == "" && k.isMethod("set_position") {
+		sp, err := sdc.lastPositions(k.Component)
 		if err != nil {
 			return err
 		}
+		names := sp.names
 
 		n, err := sdc.findSwitchArg(k)
 		if err != nil {
@@ -251,26 +413,15 @@ func (sdc *streamdeckComponent) updateKey(ctx context.Context, k KeyConfig) erro
 			return fmt.Errorf("invalid position %d", n)
 		}
 
-		if k.Color == "" && k.TextColor == "" {
-			pos, err := s.GetPosition(ctx, nil)
-			if err != nil {
-				return err
-			}
-
-			if pos == n {
-				k.TextColor = "black"
-				k.Color = "white"
-			} else {
-				k.TextColor = "white"
-				k.Color = "black"
-			}
-		}
-
 		k.Text = names[n]
 	}
 
-	if k.Text != "" {
-		return sdc.sd.WriteText(k.Key, sdc.ms.SimpleTextButton(k.Text, k.Color, k.TextColor, k.TextFont))
+	if k.Image != "" || k.Text != "" || k.Badge != nil {
+		img, err := sdc.ms.drawKey(k)
+		if err != nil {
+			return err
+		}
+		return sdc.sd.FillImage(k.Key, img)
 	}
 
 	return fmt.Errorf("nothing to display for key %v", k)
@@ -297,15 +448,16 @@ func (sdc *streamdeckComponent) applyKeys(ctx context.Context, keys []KeyConfig)
 		}
 	}
 
-	// Apply all the new keys
+	// Apply all the new keys, one that can't be drawn doesn't stop the rest
+	var errs error
 	for _, k := range keys {
 		err := sdc.updateKey(ctx, k)
 		if err != nil {
-			return err
+			errs = multierr.Append(errs, fmt.Errorf("key %d: %w", k.Key, err))
 		}
 		sdc.keys[k.Key] = k
 	}
-	return nil
+	return errs
 }
 
 // updateKeys resolves which keys should be displayed based on the current
@@ -348,21 +500,6 @@ func (sdc *streamdeckComponent) findSwitchArg(k KeyConfig) (uint32, error) {
 	return 0, fmt.Errorf("need 1 number arg, got: %v", k.Args)
 }
 
-func (sdc *streamdeckComponent) findSwitch(ctx context.Context, name string) (toggleswitch.Switch, error) {
-	r, ok := vmodutils.FindDep(sdc.deps, name)
-	if !ok {
-		return nil, fmt.Errorf("no resource %s", name)
-	}
-
-	s, ok := r.(toggleswitch.Switch)
-	if !ok {
-		return nil, fmt.Errorf("%s is a %T not switch", name, r)
-	}
-
-	return s, nil
-
-}
-
 func (sdc *streamdeckComponent) getKeyConfig(which int) (*KeyConfig, error) {
 	sdc.configLock.Lock()
 	defer sdc.configLock.Unlock()
@@ -374,63 +511,35 @@ func (sdc *streamdeckComponent) getKeyConfig(which int) (*KeyConfig, error) {
 	return &k, nil
 }
 
-func (sdc *streamdeckComponent) getResourceAndCommandForKey(which int, e streamdeck.Event) (resource.Resource, map[string]interface{}, error) {
+// getResource finds a resource by name, the deck itself or one of its dependencies
+func (sdc *streamdeckComponent) getResource(name string) (resource.Resource, error) {
+	if sdc.isSelfReference(name) {
+		return sdc, nil
+	}
+
 	sdc.configLock.Lock()
 	defer sdc.configLock.Unlock()
 
-	k, ok := sdc.keys[which]
+	r, ok := vmodutils.FindDep(sdc.deps, name)
 	if !ok {
-		return nil, nil, fmt.Errorf("no key for %v", e)
-	}
-
-	var r resource.Resource
-	// Check if this is a self-reference
-	if sdc.isSelfReference(k.Component) {
-		r = sdc
-	} else {
-		r, ok = vmodutils.FindDep(sdc.deps, k.Component)
-		if !ok {
-			return nil, nil, fmt.Errorf("no resource %s for %s", k.Component, e)
-		}
-	}
-
-	cmd := map[string]interface{}{}
-
-	if len(k.Args) > 0 {
-		cmd, ok = k.Args[0].(map[string]interface{})
-		if !ok {
-			return nil, nil, fmt.Errorf("args wrong for %v %v %T", e, k.Args[0], k.Args[0])
-		}
+		return nil, fmt.Errorf("no resource %s", name)
 	}
-
-	return r, cmd, nil
+	return r, nil
 }
 
-func (sdc *streamdeckComponent) getResourceAndCommandForDial(which int) (resource.Resource, string, error) {
-	sdc.configLock.Lock()
-	defer sdc.configLock.Unlock()
-
-	for _, dc := range sdc.conf.Dials {
-		if which != dc.Dial {
-			continue
-		}
-
-		var r resource.Resource
-		var ok bool
-		// Check if this is a self-reference
-		if sdc.isSelfReference(dc.Component) {
-			r = sdc
-		} else {
-			r, ok = vmodutils.FindDep(sdc.deps, dc.Component)
-			if !ok {
-				return nil, "", fmt.Errorf("no resource %s", dc.Component)
-			}
-		}
+// runAction calls the action's method on its component
+func (sdc *streamdeckComponent) runAction(ctx context.Context, ac *ActionConfig) (interface{}, error) {
+	a, err := findKeyAction(ac.Method)
+	if err != nil {
+		return nil, err
+	}
 
-		return r, dc.Command, nil
+	r, err := sdc.getResource(ac.Component)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, "", fmt.Errorf("no config for dial %d", which)
+	return a.run(ctx, r, ac.Args)
 }
 
 func (sdc *streamdeckComponent) handleKeyPress(ctx context.Context, s streamdeck.State, e streamdeck.Event, which int) error {
@@ -439,46 +548,99 @@ func (sdc *streamdeckComponent) handleKeyPress(ctx context.Context, s streamdeck
 		return err
 	}
 
-	if k.snakeMethod() == "DoCommand" {
-		r, cmd, err := sdc.getResourceAndCommandForKey(which, e)
-		if err != nil {
-			return err
-		}
+	if k.Confirm && !sdc.confirmPress(ctx, *k) {
+		return nil
+	}
 
-		res, err := r.DoCommand(ctx, cmd)
-		if err != nil {
-			return err
-		}
-		sdc.logger.Infof("event %v got result %v", e, res)
+	if len(k.Macro) > 0 {
+		sdc.startMacro(*k, e)
 		return nil
-	} else if k.snakeMethod() == "SetPosition" {
-		s, err := sdc.findSwitch(ctx, k.Component)
-		if err != nil {
-			return err
-		}
+	}
+
+	if len(k.States) > 0 {
+		sdc.pressStateKey(*k, e)
+		return nil
+	}
 
-		n, err := sdc.findSwitchArg(*k)
+	if k.Component == "" {
+		// only shows a sensor or state
+		return nil
+	}
+
+	sdc.runKeyAction(*k, k.policy(), func(ctx context.Context) {
+		res, err := sdc.runAction(ctx, k.action())
+		sdc.feedback(e, err)
 		if err != nil {
-			return err
+			sdc.logger.Errorf("event %v failed: %v", e, err)
+			return
 		}
+		sdc.logger.Infof("event %v got result %v", e, res)
+	})
+	return nil
+}
 
-		return s.SetPosition(ctx, n, nil)
-
-	} else {
-		return fmt.Errorf("can't handle command %v", k.snakeMethod())
+func (sdc *streamdeckComponent) getDialConfig(which int) (DialConfig, error) {
+	for _, dc := range sdc.conf.Dials {
+		if dc.Dial == which {
+			return dc, nil
+		}
 	}
+	return DialConfig{}, fmt.Errorf("no config for dial %d", which)
 }
 
 func (sdc *streamdeckComponent) handleDialTurn(ctx context.Context, s streamdeck.State, which int) error {
 	sdc.logger.Infof("handleDialTurn called which: %v state: %v", which, s.DialPos[which])
-	r, c, err := sdc.getResourceAndCommandForDial(which)
+
+	sdc.configLock.Lock()
+	dc, err := sdc.getDialConfig(which)
 	if err != nil {
+		sdc.configLock.Unlock()
 		return err
 	}
+	turn := sdc.turnDial(dc, s.DialPos[which])
+	err = sdc.updateTouchStrip(ctx)
+	if err != nil {
+		sdc.logger.Warnf("can't update touch strip: %v", err)
+	}
+	sdc.configLock.Unlock()
 
-	switch c {
+	if dc.Component == "" {
+		// only has a press action
+		return nil
+	}
+
+	sdc.runInputAction(dialInput(which), dc.TimeoutMs, orQueue(dc.Policy), func(ctx context.Context) {
+		err := sdc.runDialTurn(ctx, dc, turn)
+		if err != nil {
+			sdc.logger.Errorf("dial %d turn failed: %v", which, err)
+		}
+		if reads := dc.reads(); len(reads) > 0 {
+			// show what the turn did
+			sdc.readNow(ctx, reads...)
+			sdc.configLock.Lock()
+			if err := sdc.updateTouchStrip(ctx); err != nil {
+				sdc.logger.Warnf("can't update touch strip: %v", err)
+			}
+			sdc.configLock.Unlock()
+		}
+		sdc.feedback(streamdeck.Event{Kind: streamdeck.EventDialTurn, Which: which}, err)
+	})
+	return nil
+}
+
+func (sdc *streamdeckComponent) runDialTurn(ctx context.Context, dc DialConfig, turn dialTurn) error {
+	r, err := sdc.getResource(dc.Component)
+	if err != nil {
+		return err
+	}
+
+	switch dc.Command {
 	case "DoCommand":
-		res, err := r.DoCommand(ctx, map[string]any{c: float64(s.DialPos[which])})
+		cmd, err := dc.payload(turn)
+		if err != nil {
+			return err
+		}
+		res, err := r.DoCommand(ctx, cmd)
 		if err != nil {
 			return err
 		}
@@ -489,10 +651,13 @@ func (sdc *streamdeckComponent) handleDialTurn(ctx context.Context, s streamdeck
 		if !ok {
 			return fmt.Errorf("resource %v is not a switch", r)
 		}
-		return sw.SetPosition(ctx, uint32(s.DialPos[which]), nil)
+		if turn.value < 0 {
+			return fmt.Errorf("can't set switch %s to negative position %v", dc.Component, turn.value)
+		}
+		return sw.SetPosition(ctx, uint32(math.Round(turn.value)), nil)
 	}
 
-	return fmt.Errorf("can't handle command %v", c)
+	return fmt.Errorf("can't handle command %v", dc.Command)
 }
 
 func (sdc *streamdeckComponent) HandleEvent(ctx context.Context, s streamdeck.State, e streamdeck.Event) error {
@@ -500,11 +665,19 @@ func (sdc *streamdeckComponent) HandleEvent(ctx context.Context, s streamdeck.St
 
 	switch e.Kind {
 	case streamdeck.EventKeyPressed:
-		return nil
+		return sdc.handleKeyDown(ctx, s, e)
 	case streamdeck.EventKeyReleased:
-		return sdc.handleKeyPress(ctx, s, e, e.Which)
+		return sdc.handleKeyUp(ctx, s, e)
 	case streamdeck.EventDialTurn:
 		return sdc.handleDialTurn(ctx, s, e.Which)
+	case streamdeck.EventDialPressed:
+		return sdc.handleDialPress(ctx, e)
+	case streamdeck.EventDialReleased:
+		return nil
+	case EventTouchTap, EventTouchLongPress:
+		return sdc.handleTouch(ctx, e)
+	case EventTouchSwipeLeft, EventTouchSwipeRight:
+		return sdc.handleSwipe(ctx, e)
 	}
 
 	return fmt.Errorf("HandleEvent for %v not done", e)
@@ -516,18 +689,48 @@ func (sdc *streamdeckComponent) Name() resource.Name {
 
 func (sdc *streamdeckComponent) stateChecker() {
 	for sdc.closed.Load() == 0 {
+		sdc.checkState(context.Background())
+		time.Sleep(time.Second)
+	}
+}
+
+// checkState reopens the deck if it was unplugged, then redraws it from the current state
+func (sdc *streamdeckComponent) checkState(ctx context.Context) {
+	sdc.sd.checkAttached()
 
-		err := sdc.reconfigure(context.Background(), sdc.deps, sdc.conf)
+	if !sdc.sd.connected() {
+		reconnected, err := sdc.sd.reconnect()
 		if err != nil {
-			sdc.logger.Errorf("can't reconfigure: %v", err)
+			sdc.logger.Debugf("streamdeck still gone: %v", err)
+			return
 		}
+		if reconnected {
+			sdc.logger.Infof("streamdeck reconnected")
+		}
+	}
 
-		time.Sleep(time.Second)
+	sdc.configLock.Lock()
+	deps, conf := sdc.deps, sdc.conf
+	sdc.configLock.Unlock()
+
+	err := sdc.reconfigure(ctx, deps, conf)
+	if err != nil {
+		sdc.logger.Errorf("can't reconfigure: %v", err)
 	}
 }
 
 func (sdc *streamdeckComponent) Close(ctx context.Context) error {
+	// no new actions start once closed is set
+	sdc.pressLock.Lock()
 	sdc.closed.Store(1)
+	sdc.pressLock.Unlock()
+
+	// cancel anything still running
+	sdc.closeCancel()
+	sdc.workers.Wait()
+	sdc.runLeftovers()
+	sdc.stopPresses()
+	sdc.releaseHeldKeys()
 	return multierr.Combine(sdc.sd.ClearAllBtns(), sdc.sd.Close())
 }
 
@@ -545,6 +748,18 @@ func (sdc *streamdeckComponent) DoCommand(ctx context.Context, cmd map[string]in
 		}, nil
 	}
 
+	if theme, ok := cmd["set_theme"].(string); ok {
+		err := sdc.setTheme(ctx, theme)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]interface{}{
+			"success": true,
+			"theme":   theme,
+		}, nil
+	}
+
 	if updateData, ok := cmd["update_display"]; ok {
 		updateMap, ok := updateData.(map[string]interface{})
 		if !ok {
@@ -553,10 +768,15 @@ func (sdc *streamdeckComponent) DoCommand(ctx context.Context, cmd map[string]in
 		return sdc.handleUpdateDisplay(ctx, updateMap)
 	}
 
-	return nil, fmt.Errorf("unknown command, supported commands: set_page, update_display")
+	return nil, fmt.Errorf("unknown command, supported commands: set_page, set_theme, update_display")
 }
 
 func (sdc *streamdeckComponent) setPage(ctx context.Context, pageName string) error {
+	sdc.configLock.Lock()
+	deps, reads := sdc.deps, sdc.conf.pageReads(pageName)
+	sdc.configLock.Unlock()
+	sdc.fetchReads(ctx, deps, reads)
+
 	sdc.configLock.Lock()
 	defer sdc.configLock.Unlock()
 
@@ -577,11 +797,41 @@ func (sdc *streamdeckComponent) setPage(ctx context.Context, pageName string) er
 
 	// Update to the new page
 	sdc.currentPage = pageName
+	sdc.cancelConfirms()
 
 	// Load the new keys
 	return sdc.applyKeys(ctx, keys)
 }
 
+// saveKeyInConfig records a runtime change to a key in the keys or page being shown,
+// so that redraws and reconnects keep it
+func (sdc *streamdeckComponent) saveKeyInConfig(k KeyConfig) {
+	usingPages := len(sdc.conf.Pages) > 0
+
+	keys := sdc.conf.Keys
+	if usingPages {
+		keys = sdc.conf.Pages[sdc.currentPage]
+	}
+
+	found := false
+	for i := range keys {
+		if keys[i].Key == k.Key {
+			keys[i] = k
+			found = true
+			break
+		}
+	}
+	if !found {
+		keys = append(keys, k)
+	}
+
+	if usingPages {
+		sdc.conf.Pages[sdc.currentPage] = keys
+	} else {
+		sdc.conf.Keys = keys
+	}
+}
+
 func (sdc *streamdeckComponent) handleUpdateDisplay(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
 	sdc.configLock.Lock()
 	defer sdc.configLock.Unlock()
@@ -636,18 +886,7 @@ func (sdc *streamdeckComponent) handleUpdateDisplay(ctx context.Context, cmd map
 			// Update internal state
 			sdc.keys[keyNum] = newKey
 
-			// Also update the key in the config array if it exists
-			foundInConfig := false
-			for i := range sdc.conf.Keys {
-				if sdc.conf.Keys[i].Key == keyNum {
-					sdc.conf.Keys[i] = newKey
-					foundInConfig = true
-					break
-				}
-			}
-			if !foundInConfig {
-				sdc.conf.Keys = append(sdc.conf.Keys, newKey)
-			}
+			sdc.saveKeyInConfig(newKey)
 
 			updatedKeys = append(updatedKeys, keyNum)
 		}
@@ -698,6 +937,11 @@ func (sdc *streamdeckComponent) handleUpdateDisplay(ctx context.Context, cmd map
 			updatedDials = append(updatedDials, dialNum)
 		}
 
+		err := sdc.updateTouchStrip(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update touch strip: %w", err)
+		}
+
 		updated["dials"] = updatedDials
 	}
 
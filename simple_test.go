@@ -0,0 +1,263 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"sync"
+	"testing"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/generic"
+	"go.viam.com/test"
+
+	"golang.org/x/image/colornames"
+)
+
+type testThing struct {
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+
+	name resource.Name
+
+	lock sync.Mutex
+	cmds []map[string]interface{}
+}
+
+func (tt *testThing) Name() resource.Name {
+	return tt.name
+}
+
+func (tt *testThing) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	tt.lock.Lock()
+	defer tt.lock.Unlock()
+	tt.cmds = append(tt.cmds, cmd)
+	return cmd, nil
+}
+
+func (tt *testThing) commands() []map[string]interface{} {
+	tt.lock.Lock()
+	defer tt.lock.Unlock()
+	return append([]map[string]interface{}{}, tt.cmds...)
+}
+
+func newTestDeck(t *testing.T, conf *Config) (*streamdeckComponent, *FakeDeck, *testThing) {
+	thing := &testThing{name: generic.Named("foo")}
+	deps := resource.Dependencies{thing.name: thing}
+
+	fd := NewFakeDeck(streamdeck.Plus)
+	r, err := NewStreamDeck(context.Background(), generic.Named("deck"), deps, fd.Model(), conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	t.Cleanup(func() {
+		test.That(t, r.Close(context.Background()), test.ShouldBeNil)
+		test.That(t, fd.Closed(), test.ShouldBeTrue)
+	})
+
+	return r.(*streamdeckComponent), fd, thing
+}
+
+// waitForActions waits for the key actions started so far to finish, they run on workers
+func waitForActions(sdc *streamdeckComponent) {
+	sdc.workers.Wait()
+}
+
+func keyColor(fd *FakeDeck, key int) color.RGBA {
+	r, g, b, a := fd.Key(key).At(1, 1).RGBA()
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
+
+func TestNewStreamDeck(t *testing.T) {
+	conf := &Config{
+		Brightness: 50,
+		Keys: []KeyConfig{
+			{Key: 0, Text: "a", Color: "purple", Component: "foo", Method: "do_command", Args: []interface{}{map[string]interface{}{"x": 1.0}}},
+			{Key: 1, Text: "b", Color: "green", Component: "foo", Method: "do_command"},
+		},
+	}
+
+	sdc, fd, thing := newTestDeck(t, conf)
+	test.That(t, fd.Brightness(), test.ShouldEqual, 50)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Purple)
+	test.That(t, keyColor(fd, 1), test.ShouldResemble, colornames.Green)
+	test.That(t, keyColor(fd, 2), test.ShouldResemble, color.RGBA{0, 0, 0, 255})
+
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, thing.commands(), test.ShouldResemble, []map[string]interface{}{{"x": 1.0}})
+}
+
+// brokenDeck fails to set its brightness
+type brokenDeck struct {
+	*FakeDeck
+}
+
+func (d brokenDeck) SetBrightness(b uint16) error {
+	return errors.New("broken")
+}
+
+func TestNewStreamDeckFails(t *testing.T) {
+	fd := NewFakeDeck(streamdeck.Plus)
+	ms := fd.Model()
+	ms.Open = func(ms *ModelSetup, conf *Config) (Deck, error) {
+		return brokenDeck{fd}, nil
+	}
+
+	conf := &Config{Brightness: 50, Keys: []KeyConfig{{Key: 0, Text: "a", Component: "foo", Method: "do_command"}}}
+	_, err := NewStreamDeck(context.Background(), generic.Named("deck"), nil, ms, conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, fd.Closed(), test.ShouldBeTrue)
+}
+
+func TestSetPage(t *testing.T) {
+	conf := &Config{
+		InitialPage: "main",
+		Pages: map[string][]KeyConfig{
+			"main": {
+				{Key: 0, Text: "go", Color: "blue", Component: "deck", Method: "do_command", Args: []interface{}{map[string]interface{}{"set_page": "other"}}},
+				{Key: 1, Text: "x", Color: "green", Component: "foo", Method: "do_command"},
+			},
+			"other": {
+				{Key: 0, Text: "back", Color: "red", Component: "deck", Method: "do_command", Args: []interface{}{map[string]interface{}{"set_page": "main"}}},
+			},
+		},
+	}
+
+	sdc, fd, _ := newTestDeck(t, conf)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Blue)
+	test.That(t, keyColor(fd, 1), test.ShouldResemble, colornames.Green)
+
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, sdc.currentPage, test.ShouldEqual, "other")
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Red)
+	test.That(t, keyColor(fd, 1), test.ShouldResemble, color.RGBA{0, 0, 0, 255})
+
+	res, err := sdc.DoCommand(context.Background(), map[string]interface{}{"set_page": "main"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res["page"], test.ShouldEqual, "main")
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Blue)
+
+	_, err = sdc.DoCommand(context.Background(), map[string]interface{}{"set_page": "nope"})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestHandleUpdateDisplay(t *testing.T) {
+	conf := &Config{
+		Keys: []KeyConfig{
+			{Key: 0, Text: "a", Color: "purple", Component: "foo", Method: "do_command"},
+		},
+	}
+
+	sdc, fd, thing := newTestDeck(t, conf)
+
+	res, err := sdc.DoCommand(context.Background(), map[string]interface{}{
+		"update_display": map[string]interface{}{
+			"brightness": 20,
+			"keys": map[string]interface{}{
+				"0": map[string]interface{}{"color": "yellow"},
+				"3": map[string]interface{}{"text": "new", "color": "orange", "component": "foo", "method": "do_command"},
+			},
+			"dials": map[string]interface{}{
+				"0": map[string]interface{}{"component": "foo", "command": "DoCommand"},
+			},
+		},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res["brightness"], test.ShouldEqual, 20)
+	test.That(t, res["keys"], test.ShouldHaveLength, 2)
+	test.That(t, res["dials"], test.ShouldResemble, []int{0})
+
+	test.That(t, fd.Brightness(), test.ShouldEqual, 20)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Yellow)
+	test.That(t, keyColor(fd, 3), test.ShouldResemble, colornames.Orange)
+
+	fd.TurnDial(0, 3)
+	waitForActions(sdc)
+	test.That(t, thing.commands(), test.ShouldResemble, []map[string]interface{}{{"DoCommand": 53.0}})
+
+	_, err = sdc.DoCommand(context.Background(), map[string]interface{}{"update_display": map[string]interface{}{}})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestReconnect(t *testing.T) {
+	conf := &Config{
+		Brightness:  50,
+		InitialPage: "main",
+		Pages: map[string][]KeyConfig{
+			"main": {
+				{Key: 0, Text: "go", Color: "blue", Component: "deck", Method: "do_command", Args: []interface{}{map[string]interface{}{"set_page": "other"}}},
+			},
+			"other": {
+				{Key: 0, Text: "back", Color: "red", Component: "deck", Method: "do_command", Args: []interface{}{map[string]interface{}{"set_page": "main"}}},
+			},
+		},
+	}
+
+	sdc, fd, _ := newTestDeck(t, conf)
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, sdc.currentPage, test.ShouldEqual, "other")
+
+	fd.Unplug()
+
+	// changes while unplugged are kept and shown once it is back
+	_, err := sdc.DoCommand(context.Background(), map[string]interface{}{
+		"update_display": map[string]interface{}{
+			"brightness": 30,
+			"keys": map[string]interface{}{
+				"1": map[string]interface{}{"text": "new", "color": "orange", "component": "foo", "method": "do_command"},
+			},
+		},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sdc.sd.connected(), test.ShouldBeFalse)
+
+	sdc.checkState(context.Background())
+	test.That(t, sdc.sd.connected(), test.ShouldBeFalse)
+
+	fd.Plug()
+	sdc.checkState(context.Background())
+	test.That(t, sdc.sd.connected(), test.ShouldBeTrue)
+	test.That(t, sdc.currentPage, test.ShouldEqual, "other")
+	test.That(t, fd.Brightness(), test.ShouldEqual, 30)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Red)
+	test.That(t, keyColor(fd, 1), test.ShouldResemble, colornames.Orange)
+
+	// presses work again
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, sdc.currentPage, test.ShouldEqual, "main")
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Blue)
+}
+
+func TestRedrawOnlyChanged(t *testing.T) {
+	conf := &Config{
+		Keys: []KeyConfig{
+			{Key: 0, Text: "a", Color: "purple", Component: "foo", Method: "do_command"},
+			{Key: 1, Text: "b", Color: "green", Component: "foo", Method: "do_command"},
+		},
+	}
+
+	sdc, fd, _ := newTestDeck(t, conf)
+	test.That(t, fd.Writes(0), test.ShouldEqual, 1)
+	test.That(t, fd.Writes(1), test.ShouldEqual, 1)
+
+	sdc.checkState(context.Background())
+	sdc.checkState(context.Background())
+	test.That(t, fd.Writes(0), test.ShouldEqual, 1)
+	test.That(t, fd.Writes(1), test.ShouldEqual, 1)
+
+	_, err := sdc.DoCommand(context.Background(), map[string]interface{}{
+		"update_display": map[string]interface{}{
+			"keys": map[string]interface{}{"1": map[string]interface{}{"color": "red"}},
+		},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	sdc.checkState(context.Background())
+	test.That(t, fd.Writes(0), test.ShouldEqual, 1)
+	test.That(t, fd.Writes(1), test.ShouldEqual, 2)
+	test.That(t, keyColor(fd, 1), test.ShouldResemble, colornames.Red)
+}
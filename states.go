@@ -0,0 +1,139 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+// KeyState is one state of a key with states, how the key looks in it and what pressing it does
+type KeyState struct {
+	Text      string
+	TextColor string `json:"text_color,omitempty"`
+	Color     string
+	Image     string
+
+	Component string        `json:"component,omitempty"` // defaults to the key's
+	Method    string        `json:"method,omitempty"`    // without one, pressing only moves to the next state
+	Args      []interface{} `json:"args,omitempty"`
+}
+
+func (ks *KeyState) Validate(kc *KeyConfig) error {
+	if ks.Image != "" {
+		if _, ok := assetImages[ks.Image]; !ok {
+			return fmt.Errorf("unknown image %s", ks.Image)
+		}
+	}
+	if ks.Method == "" {
+		if ks.Component != "" || len(ks.Args) > 0 {
+			return fmt.Errorf("need a method")
+		}
+		return nil
+	}
+	return kc.stateAction(*ks).Validate()
+}
+
+func (kc *KeyConfig) validateStates() error {
+	if kc.Method != "" || len(kc.Macro) > 0 {
+		return fmt.Errorf("key %d: can't have both states and a method or macro", kc.Key)
+	}
+	if len(kc.States) < 2 {
+		return fmt.Errorf("key %d: need at least 2 states", kc.Key)
+	}
+	for i, ks := range kc.States {
+		if err := ks.Validate(kc); err != nil {
+			return fmt.Errorf("key %d state %d: %w", kc.Key, i, err)
+		}
+	}
+	return nil
+}
+
+func (kc *KeyConfig) stateAction(ks KeyState) *ActionConfig {
+	c := ks.Component
+	if c == "" {
+		c = kc.Component
+	}
+	return &ActionConfig{Component: c, Method: ks.Method, Args: ks.Args}
+}
+
+// withState returns the key as it is in state i, looking like it and with its action
+func (kc *KeyConfig) withState(i int) KeyConfig {
+	k := *kc
+	ks := kc.States[i]
+	k.States = nil
+	k.Follow = ""
+
+	if ks.Text != "" {
+		k.Text = ks.Text
+	}
+	if ks.TextColor != "" {
+		k.TextColor = ks.TextColor
+	}
+	if ks.Color != "" {
+		k.Color = ks.Color
+	}
+	if ks.Image != "" {
+		k.Image = ks.Image
+	}
+
+	if ks.Method == "" {
+		k.Component = ""
+	} else {
+		a := kc.stateAction(ks)
+		k.Component, k.Method, k.Args = a.Component, a.Method, a.Args
+	}
+	return k
+}
+
+func (sdc *streamdeckComponent) keyStateID(key int) string {
+	return fmt.Sprintf("%s/%d", sdc.currentPage, key)
+}
+
+// keyStateIndex returns which state a key is in, the last read position of the switch it follows or where presses have moved it.
+// configLock must be held.
+func (sdc *streamdeckComponent) keyStateIndex(k KeyConfig) (int, error) {
+	if k.Follow != "" {
+		pos, err := sdc.lastPosition(k.Follow)
+		if err != nil {
+			return 0, err
+		}
+		return min(int(pos), len(k.States)-1), nil
+	}
+	return sdc.keyStates[sdc.keyStateID(k.Key)] % len(k.States), nil
+}
+
+// pressStateKey runs the action of the state a key is in, then moves it to the next state.
+// A key following a switch moves when the switch does.
+func (sdc *streamdeckComponent) pressStateKey(k KeyConfig, e streamdeck.Event) {
+	sdc.runKeyAction(k, k.policy(), func(ctx context.Context) {
+		if k.Follow != "" {
+			sdc.readNow(ctx, positionRead(k.Follow))
+		}
+
+		sdc.configLock.Lock()
+		id := sdc.keyStateID(k.Key)
+		i, err := sdc.keyStateIndex(k)
+		sdc.configLock.Unlock()
+
+		if err == nil && k.States[i].Method != "" {
+			var res interface{}
+			res, err = sdc.runAction(ctx, k.stateAction(k.States[i]))
+			if err == nil {
+				sdc.logger.Infof("key %d state %d got result %v", k.Key, i, res)
+			}
+		}
+		if err != nil {
+			sdc.logger.Errorf("key %d state %d failed: %v", k.Key, i, err)
+		} else if k.Follow == "" {
+			sdc.configLock.Lock()
+			sdc.keyStates[id] = (i + 1) % len(k.States)
+			sdc.configLock.Unlock()
+		} else {
+			sdc.readNow(ctx, positionRead(k.Follow))
+		}
+
+		sdc.redrawKey(context.Background(), k.Key)
+		sdc.feedback(e, err)
+	})
+}
@@ -0,0 +1,111 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/generic"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+
+	"golang.org/x/image/colornames"
+)
+
+func TestToggleKey(t *testing.T) {
+	conf := &Config{
+		Keys: []KeyConfig{
+			{
+				Key: 0, Component: "foo",
+				States: []KeyState{
+					{Text: "off", Color: "red", Method: "do_command", Args: []interface{}{map[string]interface{}{"on": true}}},
+					{Text: "on", Color: "green", Method: "do_command", Args: []interface{}{map[string]interface{}{"on": false}}},
+				},
+			},
+		},
+	}
+	_, _, err := conf.Validate("")
+	test.That(t, err, test.ShouldBeNil)
+
+	sdc, fd, thing := newTestDeck(t, conf)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Red)
+
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Green)
+
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Red)
+
+	test.That(t, thing.commands(), test.ShouldResemble, []map[string]interface{}{{"on": true}, {"on": false}})
+}
+
+func TestFollowSwitchKey(t *testing.T) {
+	var lock sync.Mutex
+	position := uint32(0)
+	sw := inject.NewSwitch("light")
+	sw.GetPositionFunc = func(ctx context.Context, extra map[string]interface{}) (uint32, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return position, nil
+	}
+	sw.GetNumberOfPositionsFunc = func(ctx context.Context, extra map[string]interface{}) (uint32, []string, error) {
+		return 2, []string{"off", "on"}, nil
+	}
+	sw.SetPositionFunc = func(ctx context.Context, p uint32, extra map[string]interface{}) error {
+		lock.Lock()
+		defer lock.Unlock()
+		position = p
+		return nil
+	}
+
+	conf := &Config{
+		Keys: []KeyConfig{
+			{
+				Key: 0, Component: "light", Follow: "light",
+				States: []KeyState{
+					{Text: "off", Color: "black", Method: "set_position", Args: []interface{}{1.0}},
+					{Text: "on", Color: "yellow", Method: "set_position", Args: []interface{}{0.0}},
+				},
+			},
+		},
+	}
+	_, _, err := conf.Validate("")
+	test.That(t, err, test.ShouldBeNil)
+
+	fd := NewFakeDeck(streamdeck.Plus)
+	r, err := NewStreamDeck(context.Background(), generic.Named("deck"), resource.Dependencies{sw.Name(): sw}, fd.Model(), conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer r.Close(context.Background())
+	sdc := r.(*streamdeckComponent)
+
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Black)
+
+	fd.ClickKey(0)
+	waitForActions(sdc)
+	test.That(t, position, test.ShouldEqual, 1)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Yellow)
+
+	// moved by something else
+	lock.Lock()
+	position = 0
+	lock.Unlock()
+	sdc.checkState(context.Background())
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Black)
+}
+
+func TestKeyStatesValidate(t *testing.T) {
+	for _, kc := range []KeyConfig{
+		{Key: 0, Component: "foo", States: []KeyState{{Text: "only"}}},
+		{Key: 0, Component: "foo", Method: "do_command", States: []KeyState{{Text: "a"}, {Text: "b"}}},
+		{Key: 0, States: []KeyState{{Text: "a", Method: "do_command"}, {Text: "b"}}},
+		{Key: 0, Component: "foo", Method: "do_command", Follow: "light"},
+	} {
+		test.That(t, kc.Validate(), test.ShouldNotBeNil)
+	}
+}
@@ -0,0 +1,202 @@
+package viamstreamdeck
+
+import (
+	"fmt"
+	"slices"
+)
+
+// StateConfig is where a key's styles get the value they look at
+type StateConfig struct {
+	Component string `json:"component"`
+	// position of a switch, a field of readings, or a field of what DoCommand with Command returns
+	Source  string                 `json:"source"`
+	Field   string                 `json:"field,omitempty"`
+	Command map[string]interface{} `json:"command,omitempty"`
+}
+
+const (
+	stateSourcePosition  = "position"
+	stateSourceReadings  = "readings"
+	stateSourceDoCommand = "do_command"
+)
+
+func (sc *StateConfig) Validate() error {
+	if sc.Component == "" {
+		return fmt.Errorf("need a component")
+	}
+	switch sc.Source {
+	case stateSourcePosition:
+	case stateSourceReadings, stateSourceDoCommand:
+		if sc.Field == "" {
+			return fmt.Errorf("need a field for %s", sc.Source)
+		}
+	default:
+		return fmt.Errorf("unknown source %q, can be %s, %s or %s", sc.Source, stateSourcePosition, stateSourceReadings, stateSourceDoCommand)
+	}
+	return nil
+}
+
+// StyleRule changes how a key looks when the state matches, a rule without an op always matches
+type StyleRule struct {
+	Op    string      `json:"op,omitempty"` // >, <, == or in
+	Value interface{} `json:"value,omitempty"`
+
+	Color     string       `json:"color,omitempty"`
+	TextColor string       `json:"text_color,omitempty"`
+	Text      string       `json:"text,omitempty"`
+	Image     string       `json:"image,omitempty"`
+	Badge     *BadgeConfig `json:"badge,omitempty"`
+}
+
+func (sr *StyleRule) Validate() error {
+	switch sr.Op {
+	case "", "==":
+	case ">", "<":
+		if _, ok := toFloat(sr.Value); !ok {
+			return fmt.Errorf("%s needs a number, not %v", sr.Op, sr.Value)
+		}
+	case "in":
+		if _, ok := sr.Value.([]interface{}); !ok {
+			return fmt.Errorf("in needs a list, not %v", sr.Value)
+		}
+	default:
+		return fmt.Errorf("unknown op %q, can be >, <, == or in", sr.Op)
+	}
+	if sr.Image != "" {
+		if _, ok := assetImages[sr.Image]; !ok {
+			return fmt.Errorf("unknown image %s", sr.Image)
+		}
+	}
+	if sr.Badge != nil {
+		if err := sr.Badge.Validate(); err != nil {
+			return fmt.Errorf("badge: %w", err)
+		}
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint32:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+func stateEqual(a, b interface{}) bool {
+	fa, aok := toFloat(a)
+	fb, bok := toFloat(b)
+	if aok && bok {
+		return fa == fb
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func (sr *StyleRule) matches(v interface{}) bool {
+	switch sr.Op {
+	case "":
+		return true
+	case "==":
+		return stateEqual(v, sr.Value)
+	case "in":
+		l, _ := sr.Value.([]interface{})
+		return slices.ContainsFunc(l, func(x interface{}) bool { return stateEqual(v, x) })
+	}
+
+	have, ok := toFloat(v)
+	want, _ := toFloat(sr.Value)
+	if !ok {
+		return false
+	}
+	if sr.Op == ">" {
+		return have > want
+	}
+	return have < want
+}
+
+// apply returns k with what the rule sets
+func (sr *StyleRule) apply(k KeyConfig) KeyConfig {
+	if sr.Color != "" {
+		k.Color = sr.Color
+	}
+	if sr.TextColor != "" {
+		k.TextColor = sr.TextColor
+	}
+	if sr.Text != "" {
+		k.Text = sr.Text
+	}
+	if sr.Image != "" {
+		k.Image = sr.Image
+	}
+	if sr.Badge != nil {
+		k.Badge = sr.Badge
+	}
+	return k
+}
+
+// styles returns where a key's state comes from and its rules.
+// A set_position key without its own text or colors is highlighted when the switch is at its position.
+func (kc *KeyConfig) styles() (*StateConfig, []StyleRule) {
+	if len(kc.Styles) > 0 {
+		return kc.State, kc.Styles
+	}
+	if kc.Text == "" && kc.Color == "" && kc.TextColor == "" && kc.isMethod("set_position") && len(kc.Args) == 1 {
+		return &StateConfig{Component: kc.Component, Source: stateSourcePosition}, []StyleRule{
+			{Op: "==", Value: kc.Args[0], Color: "white", TextColor: "black"},
+			{Color: "black", TextColor: "white"},
+		}
+	}
+	return nil, nil
+}
+
+// readState gets the value a key's styles look at, from when it was last read
+func (sdc *streamdeckComponent) readState(sc *StateConfig) (interface{}, error) {
+	v, err := sdc.lastRead(sc.read())
+	if err != nil {
+		return nil, err
+	}
+	if sc.Source == stateSourcePosition {
+		return float64(v.(uint32)), nil
+	}
+
+	res, _ := v.(map[string]interface{})
+	f, ok := res[sc.Field]
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s", sc.Component, sc.Field)
+	}
+	return f, nil
+}
+
+// applyStyles returns k as its first matching style rule says it should look.
+// If the state can't be read the key is drawn without its styles, so one resource being down doesn't stop the rest of the page.
+func (sdc *streamdeckComponent) applyStyles(k KeyConfig) KeyConfig {
+	sc, rules := k.styles()
+	if sc == nil {
+		return k
+	}
+
+	v, err := sdc.readState(sc)
+	if err != nil {
+		sdc.logger.Warnf("key %d: can't read state of %s, drawing it unstyled: %v", k.Key, sc.Component, err)
+		return k
+	}
+
+	for _, r := range rules {
+		if r.matches(v) {
+			return r.apply(k)
+		}
+	}
+	return k
+}
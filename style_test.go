@@ -0,0 +1,93 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/generic"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/test"
+
+	"golang.org/x/image/colornames"
+)
+
+func TestStyleRuleMatches(t *testing.T) {
+	test.That(t, (&StyleRule{Op: "<", Value: 30.0}).matches(25), test.ShouldBeTrue)
+	test.That(t, (&StyleRule{Op: "<", Value: 30.0}).matches("low"), test.ShouldBeFalse)
+	test.That(t, (&StyleRule{Op: ">", Value: 30.0}).matches(30.0), test.ShouldBeFalse)
+	test.That(t, (&StyleRule{Op: "==", Value: 2.0}).matches(uint32(2)), test.ShouldBeTrue)
+	test.That(t, (&StyleRule{Op: "==", Value: "idle"}).matches("idle"), test.ShouldBeTrue)
+	test.That(t, (&StyleRule{Op: "in", Value: []interface{}{"error", "stalled"}}).matches("stalled"), test.ShouldBeTrue)
+	test.That(t, (&StyleRule{Op: "in", Value: []interface{}{"error", "stalled"}}).matches("ok"), test.ShouldBeFalse)
+	test.That(t, (&StyleRule{}).matches(nil), test.ShouldBeTrue)
+
+	for _, sr := range []StyleRule{{Op: "~"}, {Op: "<", Value: "x"}, {Op: "in", Value: 1.0}} {
+		test.That(t, sr.Validate(), test.ShouldNotBeNil)
+	}
+}
+
+func TestKeyStyles(t *testing.T) {
+	position := uint32(0)
+	sw := inject.NewSwitch("mode")
+	sw.GetPositionFunc = func(ctx context.Context, extra map[string]interface{}) (uint32, error) {
+		return position, nil
+	}
+	sw.GetNumberOfPositionsFunc = func(ctx context.Context, extra map[string]interface{}) (uint32, []string, error) {
+		return 2, []string{"off", "on"}, nil
+	}
+
+	conf := &Config{
+		Keys: []KeyConfig{
+			{
+				Key: 0, Text: "battery", Color: "blue",
+				State: &StateConfig{Component: "battery", Source: "readings", Field: "percent"},
+				Styles: []StyleRule{
+					{Op: "<", Value: 10.0, Color: "red", Text: "LOW"},
+					{Op: "<", Value: 30.0, Color: "yellow"},
+				},
+			},
+			{Key: 1, Component: "mode", Method: "set_position", Args: []interface{}{1.0}},
+			{
+				Key: 2, Text: "volts", Color: "blue",
+				State:  &StateConfig{Component: "battery", Source: "readings", Field: "voltage"},
+				Styles: []StyleRule{{Op: "<", Value: 11.0, Color: "red"}},
+			},
+			{Key: 3, Text: "after", Color: "green", Component: "mode", Method: "set_position", Args: []interface{}{0.0}},
+		},
+	}
+	_, _, err := conf.Validate("")
+	test.That(t, err, test.ShouldBeNil)
+
+	battery := &testSensor{testThing: testThing{name: generic.Named("battery")}, readings: map[string]interface{}{"percent": 80.0}}
+	fd := NewFakeDeck(streamdeck.Plus)
+	deps := resource.Dependencies{battery.name: battery, sw.Name(): sw}
+	r, err := NewStreamDeck(context.Background(), generic.Named("deck"), deps, fd.Model(), conf, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer r.Close(context.Background())
+	sdc := r.(*streamdeckComponent)
+
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Blue)
+	test.That(t, keyColor(fd, 1), test.ShouldResemble, colornames.Black)
+
+	// a state that can't be read draws the key unstyled, and the keys after it still draw
+	test.That(t, keyColor(fd, 2), test.ShouldResemble, colornames.Blue)
+	test.That(t, keyColor(fd, 3), test.ShouldResemble, colornames.Green)
+
+	battery.set("percent", 25.0)
+	position = 1
+	sdc.checkState(context.Background())
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Yellow)
+	test.That(t, keyColor(fd, 1), test.ShouldResemble, colornames.White)
+
+	battery.set("percent", 5.0)
+	sdc.checkState(context.Background())
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Red)
+
+	bad := &Config{Keys: []KeyConfig{{Key: 0, Text: "x", Styles: []StyleRule{{Color: "red"}}}}}
+	_, _, err = bad.Validate("")
+	test.That(t, err, test.ShouldNotBeNil)
+}
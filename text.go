@@ -0,0 +1,252 @@
+package viamstreamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/dh1tw/streamdeck"
+
+	"golang.org/x/image/font"
+)
+
+const (
+	// textBaseline is how far below a streamdeck.TextLine's PosY its baseline is drawn
+	textBaseline = 24
+
+	minTextSize = 6
+	textPadding = 4
+)
+
+// TextStyle is how a key's text is laid out
+type TextStyle struct {
+	Align       string  `json:"align,omitempty"`        // left, center or right, defaults to center
+	VAlign      string  `json:"valign,omitempty"`       // top, middle or bottom, defaults to middle
+	Size        float64 `json:"size,omitempty"`         // by default the largest that fits, up to max_size
+	MaxSize     float64 `json:"max_size,omitempty"`     // defaults to a third of the key
+	LineSpacing float64 `json:"line_spacing,omitempty"` // times the size, defaults to 1.1
+	Outline     string  `json:"outline,omitempty"`      // color around the text
+	Shadow      string  `json:"shadow,omitempty"`       // color below and right of the text
+}
+
+func (ts *TextStyle) Validate() error {
+	switch ts.Align {
+	case "", "left", "center", "right":
+	default:
+		return fmt.Errorf("unknown align %s, can be left, center or right", ts.Align)
+	}
+	switch ts.VAlign {
+	case "", "top", "middle", "bottom":
+	default:
+		return fmt.Errorf("unknown valign %s, can be top, middle or bottom", ts.VAlign)
+	}
+	if ts.Size < 0 || ts.MaxSize < 0 || ts.LineSpacing < 0 {
+		return fmt.Errorf("size, max_size and line_spacing can't be negative")
+	}
+	if ts.Size > 0 && ts.Size < minTextSize {
+		return fmt.Errorf("size has to be at least %d", minTextSize)
+	}
+	return nil
+}
+
+func (ts *TextStyle) lineSpacing() float64 {
+	if ts.LineSpacing <= 0 {
+		return 1.1
+	}
+	return ts.LineSpacing
+}
+
+func textWidth(face font.Face, s string) int {
+	_, w := placeGlyphs(face, s)
+	return w.Ceil()
+}
+
+// wrapText breaks text into lines no wider than width, at newlines and between words.
+// Words that don't fit on a line by themselves are broken, and broke says if any were.
+func wrapText(face font.Face, text string, width int) (lines []string, broke bool) {
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line != "" && textWidth(face, line+" "+word) <= width {
+				line += " " + word
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+
+			rs := []rune(word)
+			for len(rs) > 1 && textWidth(face, string(rs)) > width {
+				n := len(rs) - 1
+				for n > 1 && textWidth(face, string(rs[:n])) > width {
+					n--
+				}
+				lines = append(lines, string(rs[:n]))
+				rs = rs[n:]
+				broke = true
+			}
+			line = string(rs)
+		}
+		lines = append(lines, line)
+	}
+	return lines, broke
+}
+
+// textBlock is text wrapped at one size
+type textBlock struct {
+	face       *chainFace
+	size       float64
+	lines      []string
+	ascent     int
+	lineHeight int
+	height     int
+	broke      bool
+	widest     int
+}
+
+func newTextBlock(fc fontChain, text string, size float64, spacing float64, width int) textBlock {
+	face := fc.face(size)
+	lines, broke := wrapText(face, text, width)
+
+	m := face.Metrics()
+	b := textBlock{
+		face:       face,
+		size:       size,
+		lines:      lines,
+		ascent:     m.Ascent.Ceil(),
+		lineHeight: int(math.Round(size * spacing)),
+		broke:      broke,
+	}
+	b.height = b.ascent + m.Descent.Ceil() + (len(lines)-1)*b.lineHeight
+	for _, l := range lines {
+		b.widest = max(b.widest, textWidth(face, l))
+	}
+	return b
+}
+
+// fitText wraps text at style's size, or the largest size where it fits without breaking words
+func fitText(fc fontChain, text string, style *TextStyle, width, height int) textBlock {
+	if style.Size > 0 {
+		return newTextBlock(fc, text, style.Size, style.lineSpacing(), width)
+	}
+
+	maxSize := style.MaxSize
+	if maxSize <= 0 {
+		maxSize = float64(height) / 3
+	}
+	for size := math.Floor(maxSize); size > minTextSize; size-- {
+		b := newTextBlock(fc, text, size, style.lineSpacing(), width)
+		if !b.broke && b.widest <= width && b.height <= height {
+			return b
+		}
+	}
+	return newTextBlock(fc, text, minTextSize, style.lineSpacing(), width)
+}
+
+// textPass is the text drawn once, offset and in a color. Shadows and outlines are passes before the text.
+type textPass struct {
+	dx, dy int
+	clr    color.Color
+}
+
+// textLayout is text fit to a key
+type textLayout struct {
+	block  textBlock
+	x      []int // where each line starts
+	y      []int // each line's baseline
+	passes []textPass
+}
+
+// layoutText fits text to a key, measuring each character in the font it is drawn with
+func (ms *ModelSetup) layoutText(text string, clr string, fonts []string, style *TextStyle) textLayout {
+	if style == nil {
+		style = &TextStyle{}
+	}
+	fc := findFonts(fonts)
+
+	size := ms.Conf.ButtonSize
+	inner := size - 2*textPadding
+	b := fitText(fc, text, style, inner, inner)
+	l := textLayout{block: b}
+
+	top := (size - b.height) / 2
+	switch style.VAlign {
+	case "top":
+		top = textPadding
+	case "bottom":
+		top = size - textPadding - b.height
+	}
+
+	for idx, line := range b.lines {
+		x := (size - textWidth(b.face, line)) / 2
+		switch style.Align {
+		case "left":
+			x = textPadding
+		case "right":
+			x = size - textPadding - textWidth(b.face, line)
+		}
+		l.x = append(l.x, x)
+		l.y = append(l.y, top+b.ascent+idx*b.lineHeight)
+	}
+
+	if style.Shadow != "" {
+		d := max(1, int(math.Round(b.size/12)))
+		l.passes = append(l.passes, textPass{d, d, getColor(style.Shadow, "black")})
+	}
+	if style.Outline != "" {
+		d := max(1, int(math.Round(b.size/16)))
+		for _, dx := range []int{-d, 0, d} {
+			for _, dy := range []int{-d, 0, d} {
+				if dx != 0 || dy != 0 {
+					l.passes = append(l.passes, textPass{dx, dy, getColor(style.Outline, "black")})
+				}
+			}
+		}
+	}
+	l.passes = append(l.passes, textPass{0, 0, getColor(clr, "white")})
+	return l
+}
+
+// LayoutText lays text out to fit a key as lines for the streamdeck library, which can't draw
+// code points above U+FFFF or color emoji, and draws every line in the first of fonts. DrawText can.
+func (ms *ModelSetup) LayoutText(text string, clr string, fonts []string, style *TextStyle) []streamdeck.TextLine {
+	l := ms.layoutText(text, clr, fonts, style)
+	tt := l.block.face.chain[0].tt
+	if tt == nil {
+		tt = defaultFont.tt
+	}
+	tls := []streamdeck.TextLine{}
+	for _, p := range l.passes {
+		for idx, line := range l.block.lines {
+			tls = append(tls, streamdeck.TextLine{
+				Text:      line,
+				PosX:      l.x[idx] + p.dx,
+				PosY:      l.y[idx] - textBaseline + p.dy,
+				Font:      tt,
+				FontSize:  l.block.size,
+				FontColor: p.clr,
+			})
+		}
+	}
+	return tls
+}
+
+// DrawText lays text out to fit a key and draws it on img, each character in the first of fonts that has it
+func (ms *ModelSetup) DrawText(img *image.RGBA, text string, clr string, fonts []string, style *TextStyle) {
+	l := ms.layoutText(text, clr, fonts, style)
+	for _, p := range l.passes {
+		for idx, line := range l.block.lines {
+			l.block.face.drawString(img, l.x[idx]+p.dx, l.y[idx]+p.dy, line, p.clr)
+		}
+	}
+}
+
+// TextButton is a key showing text laid out with style on bgColor
+func (ms *ModelSetup) TextButton(text string, bgColor, textClr string, fonts []string, style *TextStyle) streamdeck.TextButton {
+	return streamdeck.TextButton{
+		Lines:   ms.LayoutText(text, textClr, fonts, style),
+		BgColor: getColor(bgColor, "black"),
+	}
+}
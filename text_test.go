@@ -0,0 +1,164 @@
+package viamstreamdeck
+
+import (
+	"image"
+	"testing"
+
+	"github.com/dh1tw/streamdeck"
+	"github.com/golang/freetype/truetype"
+
+	"golang.org/x/image/math/fixed"
+
+	"go.viam.com/test"
+)
+
+// inkBounds is the box around the pixels of img that aren't bg
+func inkBounds(img image.Image, bg uint32) image.Rectangle {
+	res := image.Rectangle{}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, _, _, _ := img.At(x, y).RGBA()
+			if r>>8 != bg {
+				res = res.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return res
+}
+
+func TestWrapText(t *testing.T) {
+	face := truetype.NewFace(streamdeck.MonoRegular, &truetype.Options{Size: 10, DPI: 72})
+
+	lines, broke := wrapText(face, "move arm\nhome", 1000)
+	test.That(t, lines, test.ShouldResemble, []string{"move arm", "home"})
+	test.That(t, broke, test.ShouldBeFalse)
+
+	w := textWidth(face, "move")
+	lines, broke = wrapText(face, "move arm to home", w)
+	test.That(t, lines, test.ShouldResemble, []string{"move", "arm", "to", "home"})
+	test.That(t, broke, test.ShouldBeFalse)
+
+	lines, broke = wrapText(face, "position", w)
+	test.That(t, lines, test.ShouldResemble, []string{"posi", "tion"})
+	test.That(t, broke, test.ShouldBeTrue)
+}
+
+func TestLayoutText(t *testing.T) {
+	ms := ModelPlus
+	size := ms.Conf.ButtonSize
+
+	draw := func(text string, style *TextStyle) image.Rectangle {
+		fd := NewFakeDeck(ms.Conf)
+		test.That(t, fd.WriteText(0, ms.TextButton(text, "black", "white", nil, style)), test.ShouldBeNil)
+		return inkBounds(fd.Key(0), 0)
+	}
+
+	// narrow text is drawn bigger than wide text, and both fit
+	wide, narrow := draw("WWWWWW", nil), draw("iii", nil)
+	test.That(t, narrow.Dy(), test.ShouldBeGreaterThan, wide.Dy())
+	for _, r := range []image.Rectangle{wide, narrow} {
+		test.That(t, r.In(image.Rect(0, 0, size, size)), test.ShouldBeTrue)
+	}
+
+	// centered by default
+	c := draw("ok", nil)
+	test.That(t, c.Min.X, test.ShouldAlmostEqual, size-c.Max.X, 3)
+	test.That(t, c.Min.Y, test.ShouldAlmostEqual, size-c.Max.Y, 6)
+
+	left := draw("ok", &TextStyle{Align: "left", VAlign: "top", Size: 12})
+	test.That(t, left.Min.X, test.ShouldBeLessThan, textPadding+3)
+	test.That(t, left.Min.Y, test.ShouldBeLessThan, size/3)
+
+	right := draw("ok", &TextStyle{Align: "right", VAlign: "bottom", Size: 12})
+	test.That(t, right.Max.X, test.ShouldBeGreaterThan, size-textPadding-3)
+	test.That(t, right.Max.Y, test.ShouldBeGreaterThan, size*2/3)
+
+	// explicit newlines
+	lines := ms.LayoutText("a\nb", "white", nil, nil)
+	test.That(t, len(lines), test.ShouldEqual, 2)
+	test.That(t, lines[1].PosY, test.ShouldBeGreaterThan, lines[0].PosY)
+
+	// outline and shadow are drawn first
+	lines = ms.LayoutText("a", "white", nil, &TextStyle{Outline: "black", Shadow: "gray"})
+	test.That(t, len(lines), test.ShouldEqual, 10)
+	test.That(t, lines[9].FontColor, test.ShouldResemble, getColor("white", "white"))
+
+	for _, ts := range []TextStyle{{Align: "middle"}, {VAlign: "center"}, {Size: 2}, {LineSpacing: -1}} {
+		test.That(t, ts.Validate(), test.ShouldNotBeNil)
+	}
+}
+
+func TestDrawText(t *testing.T) {
+	ms := ModelPlus
+	size := ms.Conf.ButtonSize
+	emoji := []string{"NotoEmoji-Regular.ttf"}
+
+	draw := func(text string, fonts []string) image.Rectangle {
+		img := newButtonImage(size, image.Black)
+		ms.DrawText(img, text, "white", fonts, nil)
+		return inkBounds(img, 0)
+	}
+
+	// above U+FFFF
+	face := findFonts(emoji).face(20)
+	adv, ok := face.GlyphAdvance('😀')
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, adv, test.ShouldBeGreaterThan, 0)
+	test.That(t, draw("😀", emoji).Empty(), test.ShouldBeFalse)
+
+	// variation selectors and joiners aren't drawn, the emoji around them are
+	glyphs, _ := placeGlyphs(face, "\u2764\ufe0f\u200d\U0001f525")
+	test.That(t, len(glyphs), test.ShouldEqual, 2)
+	test.That(t, glyphs[0].r, test.ShouldEqual, '\u2764')
+	test.That(t, glyphs[1].r, test.ShouldEqual, '\U0001f525')
+
+	// combining marks are composed, or go on the glyph before them
+	face = findFonts(nil).face(20)
+	glyphs, _ = placeGlyphs(face, "e\u0301")
+	test.That(t, glyphs, test.ShouldResemble, []placedGlyph{{r: '\u00e9'}})
+	test.That(t, draw("e\u0301", nil), test.ShouldResemble, draw("\u00e9", nil))
+	glyphs, _ = placeGlyphs(face, "x\u0301")
+	test.That(t, len(glyphs), test.ShouldEqual, 2)
+	test.That(t, glyphs[1].x, test.ShouldBeLessThanOrEqualTo, glyphs[0].x+fixed.I(20))
+
+	// the same as the streamdeck library draws
+	fd := NewFakeDeck(ms.Conf)
+	test.That(t, fd.WriteText(0, ms.TextButton("ok", "black", "white", nil, nil)), test.ShouldBeNil)
+	lib := inkBounds(fd.Key(0), 0)
+	ours := draw("ok", nil)
+	test.That(t, ours.Min.X, test.ShouldAlmostEqual, lib.Min.X, 1)
+	test.That(t, ours.Min.Y, test.ShouldAlmostEqual, lib.Min.Y, 1)
+}
+
+func TestFontFallback(t *testing.T) {
+	assetFonts["mono.ttf"] = defaultFont
+	defer delete(assetFonts, "mono.ttf")
+
+	ms := ModelPlus
+	size := ms.Conf.ButtonSize
+	draw := func(text string, fonts []string) *image.RGBA {
+		img := newButtonImage(size, image.Black)
+		ms.DrawText(img, text, "white", fonts, &TextStyle{Size: 16})
+		return img
+	}
+
+	// each character comes from the first font that has it
+	face := findFonts([]string{"NotoEmoji-Regular.ttf", "mono.ttf"}).face(16)
+	test.That(t, face.pick('\U0001f600'), test.ShouldEqual, 0)
+	test.That(t, face.pick('a'), test.ShouldEqual, 1)
+
+	mixed := draw("a\U0001f600", []string{"mono.ttf", "NotoEmoji-Regular.ttf"})
+	test.That(t, mixed.Pix, test.ShouldNotResemble, draw("a\U0001f600", []string{"mono.ttf"}).Pix)
+	test.That(t, mixed.Pix, test.ShouldNotResemble, draw("a\U0001f600", []string{"NotoEmoji-Regular.ttf"}).Pix)
+
+	// text_font is a font or a list of them
+	for _, tf := range []interface{}{"mono.ttf", []interface{}{"mono.ttf", "NotoEmoji-Regular.ttf"}} {
+		kc := KeyConfig{Key: 1, Text: "a", TextFont: tf, Component: "foo", Method: "do_command"}
+		test.That(t, kc.Validate(), test.ShouldBeNil)
+	}
+	for _, tf := range []interface{}{"nope.ttf", []interface{}{"mono.ttf", "nope.ttf"}, []interface{}{5}, 5} {
+		kc := KeyConfig{Key: 1, Text: "a", TextFont: tf, Component: "foo", Method: "do_command"}
+		test.That(t, kc.Validate(), test.ShouldNotBeNil)
+	}
+}
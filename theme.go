@@ -0,0 +1,211 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ThemeConfig is a palette of named colors, and how keys look when they don't say
+type ThemeConfig struct {
+	Colors map[string]string `json:"colors,omitempty"` // name to color, keys and styles can use the names as colors
+
+	Color     string      `json:"color,omitempty"`
+	TextColor string      `json:"text_color,omitempty"`
+	TextFont  interface{} `json:"text_font,omitempty"`
+	TextStyle *TextStyle  `json:"text_style,omitempty"`
+}
+
+func (tc *ThemeConfig) Validate() error {
+	for name, c := range tc.Colors {
+		if _, err := parseColor(c); err != nil {
+			return fmt.Errorf("color %s: %w", name, err)
+		}
+	}
+	colors := []string{tc.Color, tc.TextColor}
+	if tc.TextStyle != nil {
+		if err := tc.TextStyle.Validate(); err != nil {
+			return fmt.Errorf("text_style: %w", err)
+		}
+		colors = append(colors, tc.TextStyle.Outline, tc.TextStyle.Shadow)
+	}
+	for _, c := range colors {
+		if c == "" {
+			continue
+		}
+		if _, err := parseColor(tc.color(c)); err != nil {
+			return err
+		}
+	}
+	return checkFonts(tc.TextFont)
+}
+
+// color returns the palette's color for c, or c if it isn't in the palette
+func (tc *ThemeConfig) color(c string) string {
+	if v, ok := tc.Colors[c]; ok {
+		return v
+	}
+	return c
+}
+
+// colors returns every color the key can be drawn with
+func (kc *KeyConfig) colors() []string {
+	res := []string{kc.Color, kc.TextColor}
+	if kc.TextStyle != nil {
+		res = append(res, kc.TextStyle.Outline, kc.TextStyle.Shadow)
+	}
+	if kc.Badge != nil {
+		res = append(res, kc.Badge.Color, kc.Badge.TextColor)
+	}
+	for _, ks := range kc.States {
+		res = append(res, ks.Color, ks.TextColor)
+	}
+	for _, sr := range kc.Styles {
+		res = append(res, sr.Color, sr.TextColor)
+		if sr.Badge != nil {
+			res = append(res, sr.Badge.Color, sr.Badge.TextColor)
+		}
+	}
+	return res
+}
+
+// keyThemeName returns the theme a key on page is drawn with, its own, else its page's, else deck
+func (c *Config) keyThemeName(k KeyConfig, page, deck string) string {
+	if k.Theme != "" {
+		return k.Theme
+	}
+	if name := c.PageThemes[page]; name != "" {
+		return name
+	}
+	return deck
+}
+
+// checkKeyColors makes sure every color of a key is a color, or a name in theme, the one the key is drawn with
+func (c *Config) checkKeyColors(k KeyConfig, theme string) error {
+	t := c.Themes[theme]
+	for _, clr := range k.colors() {
+		if clr == "" {
+			continue
+		}
+		if _, err := parseColor(t.color(clr)); err != nil {
+			if theme == "" {
+				return err
+			}
+			return fmt.Errorf("%w, and theme %s doesn't have it", err, theme)
+		}
+	}
+	return nil
+}
+
+// checkKeys makes sure every key's colors work with the theme it is drawn with, deck being the deck's theme
+func (c *Config) checkKeys(deck string) error {
+	check := func(k KeyConfig, page string) error {
+		if err := c.checkTheme(k.Theme); err != nil {
+			return fmt.Errorf("key %d: %w", k.Key, err)
+		}
+		if err := c.checkKeyColors(k, c.keyThemeName(k, page, deck)); err != nil {
+			return fmt.Errorf("key %d: %w", k.Key, err)
+		}
+		return nil
+	}
+	for _, k := range c.Keys {
+		if err := check(k, ""); err != nil {
+			return err
+		}
+	}
+	for page, keys := range c.Pages {
+		for _, k := range keys {
+			if err := check(k, page); err != nil {
+				return fmt.Errorf("page %s: %w", page, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) checkTheme(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := c.Themes[name]; !ok {
+		return fmt.Errorf("unknown theme %s", name)
+	}
+	return nil
+}
+
+func (c *Config) validateThemes() error {
+	for name, t := range c.Themes {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("theme %s: %w", name, err)
+		}
+	}
+	if err := c.checkTheme(c.Theme); err != nil {
+		return err
+	}
+	for page, name := range c.PageThemes {
+		if _, ok := c.Pages[page]; !ok {
+			return fmt.Errorf("page_themes: unknown page %s", page)
+		}
+		if err := c.checkTheme(name); err != nil {
+			return fmt.Errorf("page %s: %w", page, err)
+		}
+	}
+	return c.checkKeys(c.Theme)
+}
+
+// keyTheme returns the key's theme, else its page's, else the deck's
+func (sdc *streamdeckComponent) keyTheme(k KeyConfig) (ThemeConfig, bool) {
+	t, ok := sdc.conf.Themes[sdc.conf.keyThemeName(k, sdc.currentPage, sdc.theme)]
+	return t, ok
+}
+
+// applyTheme fills in what the key doesn't set from its theme, and turns palette names into colors
+func (sdc *streamdeckComponent) applyTheme(k KeyConfig) KeyConfig {
+	t, ok := sdc.keyTheme(k)
+	if !ok {
+		return k
+	}
+	if k.Color == "" {
+		k.Color = t.Color
+	}
+	if k.TextColor == "" {
+		k.TextColor = t.TextColor
+	}
+	if k.TextFont == nil {
+		k.TextFont = t.TextFont
+	}
+	if k.TextStyle == nil {
+		k.TextStyle = t.TextStyle
+	}
+	k.Color = t.color(k.Color)
+	k.TextColor = t.color(k.TextColor)
+	if k.TextStyle != nil {
+		ts := *k.TextStyle
+		ts.Outline = t.color(ts.Outline)
+		ts.Shadow = t.color(ts.Shadow)
+		k.TextStyle = &ts
+	}
+	if k.Badge != nil {
+		b := *k.Badge
+		b.Color = t.color(b.Color)
+		b.TextColor = t.color(b.TextColor)
+		k.Badge = &b
+	}
+	return k
+}
+
+// setTheme changes the deck's theme and redraws it
+func (sdc *streamdeckComponent) setTheme(ctx context.Context, name string) error {
+	sdc.configLock.Lock()
+	defer sdc.configLock.Unlock()
+
+	if _, ok := sdc.conf.Themes[name]; !ok && name != "" {
+		return fmt.Errorf("unknown theme %s, have %v", name, slices.Sorted(maps.Keys(sdc.conf.Themes)))
+	}
+	if err := sdc.conf.checkKeys(name); err != nil {
+		return fmt.Errorf("can't use theme %s: %w", name, err)
+	}
+	sdc.theme = name
+	return sdc.updateKeys(ctx)
+}
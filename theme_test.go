@@ -0,0 +1,99 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+
+	"golang.org/x/image/colornames"
+)
+
+func TestParseColor(t *testing.T) {
+	for s, want := range map[string]color.Color{
+		"red":                  colornames.Red,
+		"Red":                  colornames.Red,
+		"#f00":                 color.NRGBA{0xff, 0, 0, 0xff},
+		"#0055AA":              color.NRGBA{0, 0x55, 0xaa, 0xff},
+		"#0055aa80":            color.NRGBA{0, 0x55, 0xaa, 0x80},
+		"rgb(1, 2, 3)":         color.NRGBA{1, 2, 3, 0xff},
+		"rgba(10,20,30, 0.5)":  color.NRGBA{10, 20, 30, 0x80},
+		" rgba(0, 0, 0, 0) ":   color.NRGBA{},
+		"rgb(255, 255, 255)":   color.NRGBA{0xff, 0xff, 0xff, 0xff},
+		"rgba(255, 0, 255, 1)": color.NRGBA{0xff, 0, 0xff, 0xff},
+	} {
+		c, err := parseColor(s)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, c, test.ShouldResemble, want)
+	}
+
+	for _, s := range []string{"", "brand", "#ff", "#gggggg", "rgb(1, 2)", "rgb(1, 2, 300)", "rgba(1, 2, 3, 2)", "rgb(1, 2, 3"} {
+		_, err := parseColor(s)
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
+
+func TestThemes(t *testing.T) {
+	conf := &Config{
+		Themes: map[string]ThemeConfig{
+			"day":   {Colors: map[string]string{"brand": "#0055aa"}, Color: "white", TextColor: "black"},
+			"night": {Colors: map[string]string{"brand": "#002040"}, Color: "black", TextColor: "white"},
+		},
+		Theme: "day",
+		Keys: []KeyConfig{
+			{Key: 0, Text: "a", Component: "foo", Method: "do_command"},
+			{Key: 1, Text: "b", Color: "brand", Component: "foo", Method: "do_command"},
+			{Key: 2, Text: "c", Color: "rgb(0, 128, 0)", Theme: "night", Component: "foo", Method: "do_command"},
+		},
+	}
+	_, _, err := conf.Validate("")
+	test.That(t, err, test.ShouldBeNil)
+
+	sdc, fd, _ := newTestDeck(t, conf)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.White)
+	test.That(t, keyColor(fd, 1), test.ShouldResemble, color.RGBA{0, 0x55, 0xaa, 0xff})
+	test.That(t, keyColor(fd, 2), test.ShouldResemble, color.RGBA{0, 128, 0, 0xff})
+
+	res, err := sdc.DoCommand(context.Background(), map[string]interface{}{"set_theme": "night"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res["theme"], test.ShouldEqual, "night")
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, colornames.Black)
+	test.That(t, keyColor(fd, 1), test.ShouldResemble, color.RGBA{0, 0x20, 0x40, 0xff})
+
+	_, err = sdc.DoCommand(context.Background(), map[string]interface{}{"set_theme": "dusk"})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// night has no accent, and isn't the theme of key 0
+	_, err = sdc.DoCommand(context.Background(), map[string]interface{}{
+		"update_display": map[string]interface{}{"keys": map[string]interface{}{"0": map[string]interface{}{"color": "accent"}}},
+	})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = sdc.DoCommand(context.Background(), map[string]interface{}{
+		"update_display": map[string]interface{}{"keys": map[string]interface{}{"0": map[string]interface{}{"color": "brand"}}},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, keyColor(fd, 0), test.ShouldResemble, color.RGBA{0, 0x20, 0x40, 0xff})
+
+	for _, bad := range []*Config{
+		{Keys: []KeyConfig{{Key: 0, Text: "a", Color: "brand", Component: "foo", Method: "do_command"}}},
+		{Theme: "day", Keys: conf.Keys[:1]},
+		{Themes: map[string]ThemeConfig{"x": {Colors: map[string]string{"brand": "blurple"}}}, Keys: conf.Keys[:1]},
+		{Themes: conf.Themes, PageThemes: map[string]string{"main": "day"}, Keys: conf.Keys[:1]},
+		// brand is only in a theme the key isn't drawn with
+		{
+			Themes: map[string]ThemeConfig{"day": conf.Themes["day"], "other": {Colors: map[string]string{"accent": "red"}}},
+			Theme:  "day",
+			Keys:   []KeyConfig{{Key: 0, Text: "a", Color: "accent", Component: "foo", Method: "do_command"}},
+		},
+		{
+			Themes:      map[string]ThemeConfig{"day": conf.Themes["day"], "other": {Colors: map[string]string{"accent": "red"}}},
+			PageThemes:  map[string]string{"main": "day"},
+			Pages:       map[string][]KeyConfig{"main": {{Key: 0, Text: "a", Color: "accent", Component: "foo", Method: "do_command"}}},
+			InitialPage: "main",
+		},
+	} {
+		_, _, err := bad.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
@@ -0,0 +1,112 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+// touch strip events, the streamdeck library only has kinds for keys and dials.
+// Which is the touched segment, for swipes the one it started in.
+const (
+	EventTouchTap streamdeck.EventKind = iota + 100
+	EventTouchLongPress
+	EventTouchSwipeLeft
+	EventTouchSwipeRight
+)
+
+func (sdc *streamdeckComponent) handleDialPress(ctx context.Context, e streamdeck.Event) error {
+	sdc.configLock.Lock()
+	var dial *DialConfig
+	for _, dc := range sdc.conf.Dials {
+		if dc.Dial == e.Which {
+			dial = &dc
+		}
+	}
+	sdc.configLock.Unlock()
+
+	if dial == nil || dial.Press == nil {
+		return nil
+	}
+	sdc.runInputAction(dialInput(e.Which), dial.TimeoutMs, orQueue(dial.Policy), func(ctx context.Context) {
+		sdc.runPressAction(ctx, e, "press", dial.Press)
+	})
+	return nil
+}
+
+// dialInput names a dial's actions for runInputAction, turns and presses of a dial share a queue
+func dialInput(which int) string {
+	return fmt.Sprintf("dial %d", which)
+}
+
+// runTouchAction runs a tap or swipe action on a worker, the touch strip's actions share a queue
+func (sdc *streamdeckComponent) runTouchAction(tc TouchConfig, e streamdeck.Event, kind string, ac *ActionConfig) {
+	if ac == nil {
+		return
+	}
+	sdc.runInputAction("touch strip", tc.TimeoutMs, orQueue(tc.Policy), func(ctx context.Context) {
+		sdc.runPressAction(ctx, e, kind, ac)
+	})
+}
+
+func (sdc *streamdeckComponent) handleTouch(ctx context.Context, e streamdeck.Event) error {
+	sdc.configLock.Lock()
+	var action *ActionConfig
+	var tc TouchConfig
+	if sdc.conf.Touch != nil {
+		tc = *sdc.conf.Touch
+		for _, seg := range tc.Segments {
+			if seg.Segment != e.Which {
+				continue
+			}
+			action = seg.Tap
+			if e.Kind == EventTouchLongPress && seg.LongPress != nil {
+				action = seg.LongPress
+			}
+		}
+	}
+	sdc.configLock.Unlock()
+
+	sdc.runTouchAction(tc, e, "touch", action)
+	return nil
+}
+
+func (sdc *streamdeckComponent) handleSwipe(ctx context.Context, e streamdeck.Event) error {
+	sdc.configLock.Lock()
+	tc := sdc.conf.Touch
+	if tc == nil {
+		sdc.configLock.Unlock()
+		return nil
+	}
+
+	action := tc.SwipeRight
+	if e.Kind == EventTouchSwipeLeft {
+		action = tc.SwipeLeft
+	}
+
+	next := ""
+	if action == nil && tc.SwipePages && len(sdc.conf.Pages) > 0 {
+		// swiping left brings in the next page, like a phone
+		names := sdc.conf.GetPageNames()
+		step := 1
+		if e.Kind == EventTouchSwipeRight {
+			step = -1
+		}
+		idx := slices.Index(names, sdc.currentPage)
+		next = names[(idx+step+len(names))%len(names)]
+	}
+	sdc.configLock.Unlock()
+
+	if next != "" {
+		err := sdc.setPage(ctx, next)
+		if err != nil {
+			return fmt.Errorf("can't swipe to page %s: %w", next, err)
+		}
+		return nil
+	}
+
+	sdc.runTouchAction(*tc, e, "swipe", action)
+	return nil
+}
@@ -0,0 +1,111 @@
+package viamstreamdeck
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/test"
+)
+
+func TestDialPressAndTouch(t *testing.T) {
+	doCmd := func(x string) *ActionConfig {
+		return &ActionConfig{Component: "foo", Method: "do_command", Args: []interface{}{map[string]interface{}{"x": x}}}
+	}
+
+	conf := &Config{
+		InitialPage: "a",
+		Pages: map[string][]KeyConfig{
+			"a": {{Key: 0, Text: "a", Component: "foo", Method: "do_command"}},
+			"b": {{Key: 0, Text: "b", Component: "foo", Method: "do_command"}},
+			"c": {{Key: 0, Text: "c", Component: "foo", Method: "do_command"}},
+		},
+		Dials: []DialConfig{{Dial: 1, Press: doCmd("dial")}},
+		Touch: &TouchConfig{
+			Segments:   []TouchSegmentConfig{{Segment: 2, Tap: doCmd("tap"), LongPress: doCmd("long")}},
+			SwipePages: true,
+		},
+	}
+
+	sdc, fd, thing := newTestDeck(t, conf)
+
+	fd.PressDial(1)
+	fd.ReleaseDial(1)
+	waitForActions(sdc)
+	fd.TurnDial(1, 2) // no turn action, so nothing happens
+	fd.Touch(EventTouchTap, 2)
+	fd.Touch(EventTouchLongPress, 2)
+	fd.Touch(EventTouchTap, 0)
+	waitForActions(sdc)
+	test.That(t, thing.commands(), test.ShouldResemble, []map[string]interface{}{{"x": "dial"}, {"x": "tap"}, {"x": "long"}})
+
+	fd.Touch(EventTouchSwipeLeft, 0)
+	test.That(t, sdc.currentPage, test.ShouldEqual, "b")
+	fd.Touch(EventTouchSwipeRight, 0)
+	fd.Touch(EventTouchSwipeRight, 0)
+	test.That(t, sdc.currentPage, test.ShouldEqual, "c")
+
+	test.That(t, sdc.HandleEvent(t.Context(), streamdeck.State{}, streamdeck.Event{Kind: 42}), test.ShouldNotBeNil)
+}
+
+func TestTouchStripDialValues(t *testing.T) {
+	conf := &Config{
+		Keys:  []KeyConfig{{Key: 0, Text: "a", Component: "foo", Method: "do_command"}},
+		Dials: []DialConfig{{Dial: 1, Component: "foo", Command: "DoCommand", Label: "volume", Bar: true}},
+	}
+
+	sdc, fd, _ := newTestDeck(t, conf)
+
+	segment := func(dial int) *image.RGBA {
+		return fd.TouchStrip().SubImage(image.Rect(dial*200, 0, dial*200+200, 100)).(*image.RGBA)
+	}
+	lit := func(img *image.RGBA) int {
+		n := 0
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+				if img.RGBAAt(x, y).R != 0 {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	test.That(t, lit(segment(0)), test.ShouldEqual, 0)
+	test.That(t, lit(segment(1)), test.ShouldBeGreaterThan, 0)
+
+	before := lit(segment(1))
+	writes := fd.TouchWrites()
+	fd.TurnDial(1, 20)
+	test.That(t, sdc.dials[1].value, test.ShouldEqual, 70)
+	test.That(t, fd.TouchWrites(), test.ShouldEqual, writes+1)
+	test.That(t, lit(segment(1)), test.ShouldNotEqual, before)
+
+	// nothing changed, so nothing is sent
+	writes = fd.TouchWrites()
+	sdc.checkState(t.Context())
+	test.That(t, fd.TouchWrites(), test.ShouldEqual, writes)
+}
+
+func TestDialSegmentBar(t *testing.T) {
+	seg := func(bar bool, fraction float64) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+		test.That(t, drawDialSegment(img, "a", "1", bar, fraction), test.ShouldBeNil)
+		return img
+	}
+	left, right := image.Pt(21, 83), image.Pt(178, 83)
+	track := color.RGBA{0x40, 0x40, 0x40, 0xff}
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+
+	test.That(t, pixel(seg(false, .5), left.X, left.Y), test.ShouldResemble, color.RGBA{})
+
+	// outside the dial's range is an empty or full bar, not no bar
+	empty := seg(true, -.5)
+	test.That(t, pixel(empty, left.X, left.Y), test.ShouldResemble, track)
+	test.That(t, pixel(empty, right.X, right.Y), test.ShouldResemble, track)
+	full := seg(true, 1.5)
+	test.That(t, pixel(full, left.X, left.Y), test.ShouldResemble, white)
+	test.That(t, pixel(full, right.X, right.Y), test.ShouldResemble, white)
+}
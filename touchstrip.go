@@ -0,0 +1,109 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+)
+
+func dialSensorID(dial int) string {
+	return fmt.Sprintf("dial %d", dial)
+}
+
+// updateTouchStrip draws each dial's label, value and bar on its segment of the touch strip.
+// configLock must be held.
+func (sdc *streamdeckComponent) updateTouchStrip(ctx context.Context) error {
+	if sdc.ms.TouchStrip.X == 0 || sdc.ms.NumDials == 0 {
+		return nil
+	}
+
+	size := image.Pt(sdc.ms.TouchStrip.X/sdc.ms.NumDials, sdc.ms.TouchStrip.Y)
+	for i := 0; i < sdc.ms.NumDials; i++ {
+		img := image.NewRGBA(image.Rectangle{Max: size})
+		draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+
+		for _, dc := range sdc.conf.Dials {
+			if dc.Dial != i {
+				continue
+			}
+			value, fraction := sdc.dialValue(dc)
+			err := drawDialSegment(img, dc.label(), value, dc.Bar, fraction)
+			if err != nil {
+				return err
+			}
+		}
+
+		if f, ok := activeFlash(sdc.segmentFlashes, i); ok {
+			err := drawSegmentFlash(img, f)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := sdc.sd.FillTouchStrip(image.Pt(i*size.X, 0), img)
+		if err != nil {
+			return fmt.Errorf("can't draw touch strip for dial %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// dialValue is the text to show for a dial, and where it is from 0 to 1 for the bar
+func (sdc *streamdeckComponent) dialValue(dc DialConfig) (string, float64) {
+	ds := sdc.dialState(dc)
+	lo, hi := sdc.dialLimits(dc)
+	value := ds.value
+
+	if p, ok := sdc.dialPosition(dc); ok {
+		// show what the switch is actually at, it may be changed by something else
+		value = float64(p)
+	}
+
+	fraction := 1.0
+	if hi > lo {
+		fraction = (value - lo) / (hi - lo)
+	}
+
+	if dc.Sensor != "" {
+		return sdc.sensorText(dialSensorID(dc.Dial), dc.Sensor, dc.Template), fraction
+	}
+
+	if sp, ok := sdc.dialPositions(dc); ok {
+		pos := int(math.Round(value))
+		if pos >= 0 && pos < len(sp.names) && sp.names[pos] != "" {
+			return sp.names[pos], fraction
+		}
+	}
+
+	return strconv.FormatFloat(math.Round(dc.output(value)*1000)/1000, 'f', -1, 64), fraction
+}
+
+// drawDialSegment draws a label at the top, the value in the middle and, with bar, a bar filled to fraction at the bottom.
+// A value outside the dial's range shows as an empty or full bar.
+func drawDialSegment(img *image.RGBA, label, value string, bar bool, fraction float64) error {
+	h := img.Bounds().Dy()
+
+	err := drawCenteredText(img, label, 18, h*22/100, color.Gray{0xaa})
+	if err != nil {
+		return err
+	}
+	err = drawCenteredText(img, value, 30, h*62/100, color.White)
+	if err != nil {
+		return err
+	}
+
+	if !bar {
+		return nil
+	}
+	fraction = min(1, max(0, fraction))
+
+	r := image.Rect(img.Bounds().Dx()/10, h*78/100, img.Bounds().Dx()*9/10, h*88/100)
+	draw.Draw(img, r, image.NewUniform(color.Gray{0x40}), image.Point{}, draw.Src)
+	r.Max.X = r.Min.X + int(float64(r.Dx())*fraction)
+	draw.Draw(img, r, image.White, image.Point{}, draw.Src)
+	return nil
+}
@@ -0,0 +1,497 @@
+package viamstreamdeck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"sync"
+	"time"
+
+	"github.com/bearsh/hid"
+	"github.com/dh1tw/streamdeck"
+)
+
+// usbProtocol is the wire protocol generation of a stream deck
+type usbProtocol int
+
+const (
+	// usbProtocolV1 is used by the Mini: bmp key images and a 1 byte input report header
+	usbProtocolV1 usbProtocol = iota
+	// usbProtocolV2 is used by the MK.2, XL and Neo: jpeg key images and a 4 byte input report header
+	usbProtocolV2
+)
+
+// keyTransform is how an image has to be turned before the device shows it upright.
+// Rotate is counter-clockwise degrees, applied before the flips.
+type keyTransform struct {
+	Rotate int
+	FlipH  bool
+	FlipV  bool
+}
+
+func (kt keyTransform) apply(img *image.RGBA) *image.RGBA {
+	size := img.Bounds().Dx()
+	out := image.NewRGBA(img.Bounds())
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			sx, sy := x, y
+			if kt.FlipH {
+				sx = size - 1 - sx
+			}
+			if kt.FlipV {
+				sy = size - 1 - sy
+			}
+			switch kt.Rotate {
+			case 90:
+				sx, sy = size-1-sy, sx
+			case 180:
+				sx, sy = size-1-sx, size-1-sy
+			case 270:
+				sx, sy = sy, size-1-sx
+			}
+			out.SetRGBA(x, y, img.RGBAAt(sx, sy))
+		}
+	}
+	return out
+}
+
+const usbReportSize = 1024
+
+// usbOpener returns a ModelSetup.Open that talks to the device directly over hid
+// rather than through the streamdeck library.
+func usbOpener(proto usbProtocol, xform keyTransform) func(ms *ModelSetup, conf *Config) (Deck, error) {
+	return func(ms *ModelSetup, conf *Config) (Deck, error) {
+		return openUSBDeck(ms, conf.serial(), proto, xform)
+	}
+}
+
+// hidDevice is what usbDeck uses of a *hid.Device
+type hidDevice interface {
+	Write(b []byte) (int, error)
+	SendFeatureReport(b []byte) (int, error)
+	ReadTimeout(b []byte, timeout int) (int, error)
+	Close() error
+}
+
+type usbDeck struct {
+	conf   streamdeck.Config
+	proto  usbProtocol
+	xform  keyTransform
+	device hidDevice
+	pids   []uint16
+	serial string
+
+	numDials   int
+	touchWidth int
+
+	lock sync.Mutex // guards writes to device and cb
+	cb   streamdeck.BtnEvent
+
+	events chan usbEvent
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type usbEvent struct {
+	s streamdeck.State
+	e streamdeck.Event
+}
+
+func openUSBDeck(ms *ModelSetup, serial string, proto usbProtocol, xform keyTransform) (*usbDeck, error) {
+	var info *hid.DeviceInfo
+	for _, pid := range ms.productIDs() {
+		for _, d := range hid.Enumerate(streamdeck.VendorID, pid) {
+			if serial == "" || d.Serial == serial {
+				info = &d
+				break
+			}
+		}
+		if info != nil {
+			break
+		}
+	}
+	if info == nil {
+		if serial != "" {
+			return nil, fmt.Errorf("no %s found with serial %s", ms.Model.Name, serial)
+		}
+		return nil, fmt.Errorf("no %s found", ms.Model.Name)
+	}
+
+	device, err := info.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &usbDeck{
+		conf:   ms.Conf,
+		proto:  proto,
+		xform:  xform,
+		device: device,
+		pids:   ms.productIDs(),
+		serial: info.Serial,
+
+		numDials:   ms.NumDials,
+		touchWidth: ms.TouchStrip.X,
+		events:     make(chan usbEvent, 64),
+	}
+	d.start()
+	return d, nil
+}
+
+// start reads the device and dispatches its events until Close
+func (d *usbDeck) start() {
+	var ctx context.Context
+	ctx, d.cancel = context.WithCancel(context.Background())
+	d.wg.Add(2)
+	go d.read(ctx)
+	go d.dispatch(ctx)
+}
+
+func (d *usbDeck) checkKey(btnIndex int) error {
+	if btnIndex < 0 || btnIndex >= d.conf.NumButtons() {
+		return fmt.Errorf("invalid key index %d", btnIndex)
+	}
+	return nil
+}
+
+func (d *usbDeck) WriteText(btnIndex int, textBtn streamdeck.TextButton) error {
+	img := newButtonImage(d.conf.ButtonSize, image.NewUniform(textBtn.BgColor))
+	err := drawTextLines(img, textBtn.Lines)
+	if err != nil {
+		return err
+	}
+	return d.FillImage(btnIndex, img)
+}
+
+func (d *usbDeck) WriteTextOnImage(btnIndex int, imgIn image.Image, lines []streamdeck.TextLine) error {
+	img := resizeImage(imgIn, d.conf.ButtonSize, d.conf.ButtonSize)
+	err := drawTextLines(img, lines)
+	if err != nil {
+		return err
+	}
+	return d.FillImage(btnIndex, img)
+}
+
+func (d *usbDeck) FillImage(btnIndex int, img image.Image) error {
+	if err := d.checkKey(btnIndex); err != nil {
+		return err
+	}
+
+	var rgba *image.RGBA
+	if img.Bounds().Dx() != d.conf.ButtonSize || img.Bounds().Dy() != d.conf.ButtonSize {
+		rgba = resizeImage(img, d.conf.ButtonSize, d.conf.ButtonSize)
+	} else {
+		rgba = newButtonImage(d.conf.ButtonSize, img)
+	}
+	rgba = d.xform.apply(rgba)
+
+	switch d.proto {
+	case usbProtocolV1:
+		return d.writeReports(encodeBMP(rgba), 16, func(header []byte, page int, last bool, n int) {
+			header[0] = 0x02
+			header[1] = 0x01
+			header[2] = byte(page)
+			if last {
+				header[4] = 1
+			}
+			header[5] = byte(btnIndex + 1)
+		})
+	default:
+		buf := bytes.Buffer{}
+		err := jpeg.Encode(&buf, rgba, &jpeg.Options{Quality: 90})
+		if err != nil {
+			return err
+		}
+		return d.writeReports(buf.Bytes(), 8, func(header []byte, page int, last bool, n int) {
+			header[0] = 0x02
+			header[1] = 0x07
+			header[2] = byte(btnIndex)
+			if last {
+				header[3] = 1
+			}
+			binary.LittleEndian.PutUint16(header[4:], uint16(n))
+			binary.LittleEndian.PutUint16(header[6:], uint16(page))
+		})
+	}
+}
+
+func (d *usbDeck) FillTouchStrip(at image.Point, img image.Image) error {
+	if d.touchWidth == 0 {
+		return errNoTouchStrip
+	}
+
+	buf := bytes.Buffer{}
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	if err != nil {
+		return err
+	}
+
+	size := img.Bounds().Size()
+	return d.writeReports(buf.Bytes(), 16, func(header []byte, page int, last bool, n int) {
+		header[0] = 0x02
+		header[1] = 0x0c
+		binary.LittleEndian.PutUint16(header[2:], uint16(at.X))
+		binary.LittleEndian.PutUint16(header[4:], uint16(at.Y))
+		binary.LittleEndian.PutUint16(header[6:], uint16(size.X))
+		binary.LittleEndian.PutUint16(header[8:], uint16(size.Y))
+		if last {
+			header[10] = 1
+		}
+		binary.LittleEndian.PutUint16(header[11:], uint16(page))
+		binary.LittleEndian.PutUint16(header[13:], uint16(n))
+	})
+}
+
+// writeReports splits data into usbReportSize reports, fillHeader writes the first headerSize bytes of each
+func (d *usbDeck) writeReports(data []byte, headerSize int, fillHeader func(header []byte, page int, last bool, n int)) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for page := 0; len(data) > 0 || page == 0; page++ {
+		n := min(len(data), usbReportSize-headerSize)
+		buf := make([]byte, usbReportSize)
+		fillHeader(buf[:headerSize], page, n == len(data), n)
+		copy(buf[headerSize:], data[:n])
+		data = data[n:]
+
+		written, err := d.device.Write(buf)
+		if err != nil {
+			return d.check(err)
+		}
+		if written != len(buf) {
+			return fmt.Errorf("only wrote %d of %d", written, len(buf))
+		}
+	}
+	return nil
+}
+
+// encodeBMP encodes img as a 24 bit bottom up bmp
+func encodeBMP(img *image.RGBA) []byte {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	rowSize := (w*3 + 3) &^ 3
+	const headerSize = 54
+
+	buf := make([]byte, headerSize+rowSize*h)
+	buf[0], buf[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(buf[2:], uint32(len(buf)))
+	binary.LittleEndian.PutUint32(buf[10:], headerSize)
+	binary.LittleEndian.PutUint32(buf[14:], 40)
+	binary.LittleEndian.PutUint32(buf[18:], uint32(w))
+	binary.LittleEndian.PutUint32(buf[22:], uint32(h))
+	binary.LittleEndian.PutUint16(buf[26:], 1)
+	binary.LittleEndian.PutUint16(buf[28:], 24)
+	binary.LittleEndian.PutUint32(buf[34:], uint32(rowSize*h))
+	binary.LittleEndian.PutUint32(buf[38:], 3780) // 96 dpi
+	binary.LittleEndian.PutUint32(buf[42:], 3780)
+
+	for y := 0; y < h; y++ {
+		row := buf[headerSize+(h-1-y)*rowSize:]
+		for x := 0; x < w; x++ {
+			c := img.RGBAAt(x, y)
+			row[x*3] = c.B
+			row[x*3+1] = c.G
+			row[x*3+2] = c.R
+		}
+	}
+	return buf
+}
+
+func (d *usbDeck) ClearBtn(btnIndex int) error {
+	return d.FillImage(btnIndex, newButtonImage(d.conf.ButtonSize, image.NewUniform(color.Black)))
+}
+
+func (d *usbDeck) ClearAllBtns() error {
+	for i := 0; i < d.conf.NumButtons(); i++ {
+		err := d.ClearBtn(i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *usbDeck) SetBrightness(b uint16) error {
+	var buf []byte
+	switch d.proto {
+	case usbProtocolV1:
+		buf = make([]byte, 17)
+		copy(buf, []byte{0x05, 0x55, 0xaa, 0xd1, 0x01, byte(b)})
+	default:
+		buf = make([]byte, 32)
+		copy(buf, []byte{0x03, 0x08, byte(b)})
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	_, err := d.device.SendFeatureReport(buf)
+	return d.check(err)
+}
+
+func (d *usbDeck) attached() bool {
+	return deviceAttached(d.pids, d.serial)
+}
+
+func (d *usbDeck) check(err error) error {
+	if err != nil && !deviceAttached(d.pids, d.serial) {
+		return fmt.Errorf("%w: %w", ErrDeckDisconnected, err)
+	}
+	return err
+}
+
+func (d *usbDeck) SetBtnEventCb(ev streamdeck.BtnEvent) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.cb = ev
+}
+
+// Close waits for the event callback to return, so it must not be called from the callback
+func (d *usbDeck) Close() error {
+	// read uses the device until it sees the cancel, within its 100ms read timeout
+	d.cancel()
+	d.wg.Wait()
+	return d.device.Close()
+}
+
+// read turns input reports into events
+func (d *usbDeck) read(ctx context.Context) {
+	defer d.wg.Done()
+
+	state := streamdeck.State{
+		Keys:     make([]bool, d.conf.NumButtons()),
+		DialPush: make([]bool, d.numDials),
+		DialPos:  make([]int, d.numDials),
+	}
+	for i := range state.DialPos {
+		state.DialPos[i] = dialStartPos
+	}
+	buf := make([]byte, 512)
+
+	for ctx.Err() == nil {
+		n, err := d.device.ReadTimeout(buf, 100)
+		if err != nil {
+			// the device is probably gone, don't spin
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		for _, e := range d.parseReport(&state, buf[:n]) {
+			s := streamdeck.State{
+				Keys:     append([]bool{}, state.Keys...),
+				DialPush: append([]bool{}, state.DialPush...),
+				DialPos:  append([]int{}, state.DialPos...),
+			}
+			select {
+			case d.events <- usbEvent{s, e}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// swipeMinDistance is how far a touch has to move to be a swipe rather than a tap
+const swipeMinDistance = 40
+
+// parseReport updates state from an input report and returns what happened
+func (d *usbDeck) parseReport(state *streamdeck.State, report []byte) []streamdeck.Event {
+	if len(report) == 0 || report[0] != 0x01 {
+		return nil
+	}
+
+	if d.proto == usbProtocolV1 {
+		return updateBools(state.Keys, report[1:], streamdeck.EventKeyPressed, streamdeck.EventKeyReleased)
+	}
+
+	if len(report) < 5 {
+		return nil
+	}
+	data := report[4:]
+
+	switch report[1] {
+	case 0x00:
+		return updateBools(state.Keys, data, streamdeck.EventKeyPressed, streamdeck.EventKeyReleased)
+	case 0x02:
+		if len(data) < 10 || d.touchWidth == 0 {
+			return nil
+		}
+		x := int(binary.LittleEndian.Uint16(data[2:]))
+		e := streamdeck.Event{Kind: EventTouchTap, Which: d.touchSegment(x)}
+		switch data[0] {
+		case 2:
+			e.Kind = EventTouchLongPress
+		case 3:
+			dx := int(binary.LittleEndian.Uint16(data[6:])) - x
+			switch {
+			case dx <= -swipeMinDistance:
+				e.Kind = EventTouchSwipeLeft
+			case dx >= swipeMinDistance:
+				e.Kind = EventTouchSwipeRight
+			}
+		}
+		return []streamdeck.Event{e}
+	case 0x03:
+		if len(data) < 1+d.numDials {
+			return nil
+		}
+		if data[0] == 0x00 {
+			return updateBools(state.DialPush, data[1:], streamdeck.EventDialPressed, streamdeck.EventDialReleased)
+		}
+		events := []streamdeck.Event{}
+		for i, delta := range data[1 : 1+d.numDials] {
+			if delta == 0 {
+				continue
+			}
+			// not clamped, the component works out how far it turned
+			state.DialPos[i] += int(int8(delta))
+			events = append(events, streamdeck.Event{Kind: streamdeck.EventDialTurn, Which: i})
+		}
+		return events
+	}
+	return nil
+}
+
+// touchSegment is which dial's part of the touch strip x is in
+func (d *usbDeck) touchSegment(x int) int {
+	return min(d.numDials-1, max(0, x*d.numDials/d.touchWidth))
+}
+
+// updateBools copies which buttons are down into state and returns an event for each change
+func updateBools(state []bool, data []byte, pressed, released streamdeck.EventKind) []streamdeck.Event {
+	events := []streamdeck.Event{}
+	for i := 0; i < len(state) && i < len(data); i++ {
+		down := data[i] != 0
+		if down == state[i] {
+			continue
+		}
+		state[i] = down
+
+		e := streamdeck.Event{Kind: released, Which: i}
+		if down {
+			e.Kind = pressed
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// dispatch calls the callback for each event in order, off the read loop
+func (d *usbDeck) dispatch(ctx context.Context) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ue := <-d.events:
+			d.lock.Lock()
+			cb := d.cb
+			d.lock.Unlock()
+			if cb != nil {
+				cb(ue.s, ue.e)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package viamstreamdeck
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/test"
+)
+
+func TestKeyTransform(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	img.SetRGBA(1, 0, color.RGBA{R: 255, A: 255}) // top middle
+
+	at := func(img *image.RGBA, x, y int) uint8 {
+		return img.RGBAAt(x, y).R
+	}
+
+	test.That(t, at(keyTransform{}.apply(img), 1, 0), test.ShouldEqual, 255)
+	test.That(t, at(keyTransform{FlipH: true, FlipV: true}.apply(img), 1, 2), test.ShouldEqual, 255)
+	test.That(t, at(keyTransform{Rotate: 180}.apply(img), 1, 2), test.ShouldEqual, 255)
+	test.That(t, at(keyTransform{Rotate: 90}.apply(img), 0, 1), test.ShouldEqual, 255)
+	test.That(t, at(keyTransform{Rotate: 270}.apply(img), 2, 1), test.ShouldEqual, 255)
+
+	// mini: rotate then flip vertically is a transpose
+	img.SetRGBA(2, 0, color.RGBA{R: 128, A: 255})
+	mini := keyTransform{Rotate: 90, FlipV: true}.apply(img)
+	test.That(t, at(mini, 0, 1), test.ShouldEqual, 255)
+	test.That(t, at(mini, 0, 2), test.ShouldEqual, 128)
+}
+
+func TestEncodeBMP(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 80, 80))
+	img.SetRGBA(0, 0, color.RGBA{R: 1, G: 2, B: 3, A: 255})
+
+	data := encodeBMP(img)
+	test.That(t, len(data), test.ShouldEqual, 54+80*80*3)
+	test.That(t, string(data[:2]), test.ShouldEqual, "BM")
+
+	// bottom up, so the top left pixel is at the start of the last row
+	last := 54 + 79*80*3
+	test.That(t, data[last:last+3], test.ShouldResemble, []byte{3, 2, 1})
+}
+
+func TestParsePlusReport(t *testing.T) {
+	d := &usbDeck{conf: streamdeck.Plus, proto: usbProtocolV2, numDials: 4, touchWidth: 800}
+	state := streamdeck.State{Keys: make([]bool, 8), DialPush: make([]bool, 4), DialPos: []int{50, 50, 50, 50}}
+
+	report := func(kind byte, data ...byte) []byte {
+		return append([]byte{0x01, kind, 0, 0}, data...)
+	}
+
+	test.That(t, d.parseReport(&state, report(0x00, 0, 1, 0, 0, 0, 0, 0, 0)), test.ShouldResemble,
+		[]streamdeck.Event{{Kind: streamdeck.EventKeyPressed, Which: 1}})
+	test.That(t, state.Keys[1], test.ShouldBeTrue)
+
+	test.That(t, d.parseReport(&state, report(0x03, 0x00, 0, 0, 1, 0)), test.ShouldResemble,
+		[]streamdeck.Event{{Kind: streamdeck.EventDialPressed, Which: 2}})
+	test.That(t, d.parseReport(&state, report(0x03, 0x00, 0, 0, 0, 0)), test.ShouldResemble,
+		[]streamdeck.Event{{Kind: streamdeck.EventDialReleased, Which: 2}})
+
+	test.That(t, d.parseReport(&state, report(0x03, 0x01, 3, 0, 0, 0xfe)), test.ShouldResemble,
+		[]streamdeck.Event{{Kind: streamdeck.EventDialTurn, Which: 0}, {Kind: streamdeck.EventDialTurn, Which: 3}})
+	test.That(t, state.DialPos, test.ShouldResemble, []int{53, 50, 50, 48})
+
+	// tap at x=450, long press at x=10, drag from x=700 to x=300
+	test.That(t, d.parseReport(&state, report(0x02, 1, 0, 0xc2, 0x01, 50, 0, 0, 0, 0, 0)), test.ShouldResemble,
+		[]streamdeck.Event{{Kind: EventTouchTap, Which: 2}})
+	test.That(t, d.parseReport(&state, report(0x02, 2, 0, 10, 0, 50, 0, 0, 0, 0, 0)), test.ShouldResemble,
+		[]streamdeck.Event{{Kind: EventTouchLongPress, Which: 0}})
+	test.That(t, d.parseReport(&state, report(0x02, 3, 0, 0xbc, 0x02, 50, 0, 0x2c, 0x01, 50, 0)), test.ShouldResemble,
+		[]streamdeck.Event{{Kind: EventTouchSwipeLeft, Which: 3}})
+}
@@ -0,0 +1,319 @@
+package viamstreamdeck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dh1tw/streamdeck"
+	"go.uber.org/multierr"
+)
+
+// VirtualDeck is a FakeDeck that is shown on a web page, clicking a key, scrolling over or clicking a dial,
+// or clicking and dragging on the touch strip sends the same events the usb device would.
+type VirtualDeck struct {
+	*FakeDeck
+
+	numDials   int
+	touchStrip image.Point
+	addr       string
+	server     *http.Server
+}
+
+// VirtualModel returns the ModelVirtual setup with the layout asked for in conf
+func VirtualModel(conf *Config) (*ModelSetup, error) {
+	return ModelVirtual.withLayout(conf.Virtual.layout())
+}
+
+func openVirtualDeck(ms *ModelSetup, conf *Config) (Deck, error) {
+	var vc *VirtualConfig
+	if conf != nil {
+		vc = conf.Virtual
+	}
+	return NewVirtualDeck(ms, fmt.Sprintf("localhost:%d", vc.port()))
+}
+
+// NewVirtualDeck starts serving a deck with the layout of ms on addr
+func NewVirtualDeck(ms *ModelSetup, addr string) (*VirtualDeck, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("virtual streamdeck can't listen on %s: %w", addr, err)
+	}
+
+	vd := &VirtualDeck{
+		FakeDeck:   NewFakeDeck(ms.Conf),
+		numDials:   ms.NumDials,
+		touchStrip: ms.TouchStrip,
+		addr:       l.Addr().String(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", vd.handleIndex)
+	mux.HandleFunc("GET /state", vd.handleState)
+	mux.HandleFunc("GET /key/{key}", vd.handleKeyImage)
+	mux.HandleFunc("GET /touch", vd.handleTouchImage)
+	mux.HandleFunc("POST /key/{key}/{action}", vd.handleKeyEvent)
+	mux.HandleFunc("POST /dial/{dial}/{action}", vd.handleDialEvent)
+	mux.HandleFunc("POST /touch/{segment}/{action}", vd.handleTouchEvent)
+
+	vd.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go vd.server.Serve(l) //nolint:errcheck
+
+	return vd, nil
+}
+
+// Addr is where the page is being served
+func (vd *VirtualDeck) Addr() string {
+	return "http://" + vd.addr
+}
+
+func (vd *VirtualDeck) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return multierr.Combine(vd.server.Shutdown(ctx), vd.FakeDeck.Close())
+}
+
+func pathInt(r *http.Request, name string, limit int) (int, error) {
+	n, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n >= limit {
+		return 0, fmt.Errorf("%s %d out of range", name, n)
+	}
+	return n, nil
+}
+
+func (vd *VirtualDeck) handleIndex(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"Columns":    vd.conf.NumButtonColumns,
+		"ButtonSize": vd.conf.ButtonSize,
+		"Keys":       make([]struct{}, vd.conf.NumButtons()),
+		"Dials":      make([]struct{}, vd.numDials),
+		"Touch":      vd.touchStrip,
+		"Segments":   make([]struct{}, vd.touchSegments()),
+		"SegmentX":   vd.touchStrip.X / max(1, vd.touchSegments()),
+	}
+	w.Header().Set("Content-Type", "text/html")
+	err := virtualPage.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleState returns the write counts for each key and the touch strip so the page knows which images to reload
+func (vd *VirtualDeck) handleState(w http.ResponseWriter, r *http.Request) {
+	vd.lock.Lock()
+	state := map[string]interface{}{
+		"writes":       append([]int{}, vd.writes...),
+		"touch_writes": vd.touchWrites,
+		"brightness":   vd.brightness,
+	}
+	vd.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state) //nolint:errcheck
+}
+
+func (vd *VirtualDeck) handleKeyImage(w http.ResponseWriter, r *http.Request) {
+	key, err := pathInt(r, "key", vd.conf.NumButtons())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	png.Encode(w, vd.Key(key)) //nolint:errcheck
+}
+
+func (vd *VirtualDeck) handleTouchImage(w http.ResponseWriter, r *http.Request) {
+	img := vd.TouchStrip()
+	if img == nil {
+		http.Error(w, "no touch strip", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	png.Encode(w, img) //nolint:errcheck
+}
+
+func (vd *VirtualDeck) handleKeyEvent(w http.ResponseWriter, r *http.Request) {
+	key, err := pathInt(r, "key", vd.conf.NumButtons())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	switch r.PathValue("action") {
+	case "press":
+		vd.PressKey(key)
+	case "release":
+		vd.ReleaseKey(key)
+	default:
+		http.Error(w, "unknown key action", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (vd *VirtualDeck) handleDialEvent(w http.ResponseWriter, r *http.Request) {
+	dial, err := pathInt(r, "dial", vd.numDials)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	switch r.PathValue("action") {
+	case "turn":
+		delta, err := strconv.Atoi(r.URL.Query().Get("delta"))
+		if err != nil {
+			http.Error(w, "need an integer delta", http.StatusBadRequest)
+			return
+		}
+		vd.TurnDial(dial, delta)
+	case "press":
+		vd.PressDial(dial)
+	case "release":
+		vd.ReleaseDial(dial)
+	default:
+		http.Error(w, "unknown dial action", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (vd *VirtualDeck) touchSegments() int {
+	if vd.touchStrip.X == 0 {
+		return 0
+	}
+	return vd.numDials
+}
+
+var touchActions = map[string]streamdeck.EventKind{
+	"tap":         EventTouchTap,
+	"long_press":  EventTouchLongPress,
+	"swipe_left":  EventTouchSwipeLeft,
+	"swipe_right": EventTouchSwipeRight,
+}
+
+func (vd *VirtualDeck) handleTouchEvent(w http.ResponseWriter, r *http.Request) {
+	segment, err := pathInt(r, "segment", vd.touchSegments())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	kind, ok := touchActions[r.PathValue("action")]
+	if !ok {
+		http.Error(w, "unknown touch action", http.StatusBadRequest)
+		return
+	}
+	vd.Touch(kind, segment)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var virtualPage = template.Must(template.New("virtual").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>virtual streamdeck</title>
+<style>
+  body { background: #333; font-family: sans-serif; color: #ccc; }
+  #deck { display: inline-grid; grid-template-columns: repeat({{.Columns}}, {{.ButtonSize}}px); gap: 12px; padding: 20px; background: #111; border-radius: 16px; }
+  #deck img { width: {{.ButtonSize}}px; height: {{.ButtonSize}}px; border-radius: 8px; cursor: pointer; user-select: none; }
+  #deck img:active { transform: scale(0.95); }
+  #dials { display: flex; gap: 12px; padding: 12px 20px; }
+  .dial { width: 60px; height: 60px; border-radius: 50%; background: #555; border: 4px solid #222; cursor: ns-resize; }
+  .dial:active { background: #777; }
+  #touch { display: flex; width: {{.Touch.X}}px; margin: 0 20px; background: #000 url(touch) no-repeat; }
+  .segment { width: {{.SegmentX}}px; height: {{.Touch.Y}}px; box-sizing: border-box; border: 1px solid #444; cursor: pointer; user-select: none; }
+</style>
+</head>
+<body>
+<div id="deck">
+{{range $i, $k := .Keys}}  <img id="key-{{$i}}" data-key="{{$i}}" src="key/{{$i}}" draggable="false">
+{{end}}</div>
+{{if .Segments}}<div id="touch">
+{{range $i, $s := .Segments}}  <div class="segment" data-segment="{{$i}}" title="click, hold or drag sideways"></div>
+{{end}}</div>
+{{end}}<div id="dials">
+{{range $i, $d := .Dials}}  <div class="dial" data-dial="{{$i}}" title="scroll to turn dial {{$i}}, click to push it"></div>
+{{end}}</div>
+<script>
+// events are sent one at a time so a release can't overtake its press
+let queue = Promise.resolve();
+const post = (url) => { queue = queue.then(() => fetch(url, { method: "POST" })).catch(() => {}); };
+
+document.querySelectorAll("#deck img").forEach((img) => {
+  let down = false;
+  const release = () => {
+    if (down) {
+      down = false;
+      post("key/" + img.dataset.key + "/release");
+    }
+  };
+  img.addEventListener("mousedown", () => {
+    down = true;
+    post("key/" + img.dataset.key + "/press");
+  });
+  img.addEventListener("mouseup", release);
+  img.addEventListener("mouseleave", release);
+});
+
+document.querySelectorAll(".dial").forEach((d) => {
+  d.addEventListener("wheel", (e) => {
+    e.preventDefault();
+    post("dial/" + d.dataset.dial + "/turn?delta=" + (e.deltaY < 0 ? 1 : -1));
+  });
+  d.addEventListener("mousedown", () => post("dial/" + d.dataset.dial + "/press"));
+  d.addEventListener("mouseup", () => post("dial/" + d.dataset.dial + "/release"));
+});
+
+// a touch is a tap, a long press if held, or a swipe if dragged sideways
+let touchStart = null;
+document.querySelectorAll(".segment").forEach((s) => {
+  s.addEventListener("mousedown", (e) => { touchStart = { segment: s.dataset.segment, x: e.clientX, t: Date.now() }; });
+});
+document.addEventListener("mouseup", (e) => {
+  if (!touchStart) {
+    return;
+  }
+  const dx = e.clientX - touchStart.x;
+  let action = Date.now() - touchStart.t > 500 ? "long_press" : "tap";
+  if (Math.abs(dx) >= 40) {
+    action = dx < 0 ? "swipe_left" : "swipe_right";
+  }
+  post("touch/" + touchStart.segment + "/" + action);
+  touchStart = null;
+});
+
+let writes = [];
+let touchWrites = 0;
+async function poll() {
+  try {
+    const state = await (await fetch("state")).json();
+    state.writes.forEach((n, i) => {
+      if (writes[i] !== n) {
+        document.getElementById("key-" + i).src = "key/" + i + "?v=" + n;
+      }
+    });
+    writes = state.writes;
+    if (state.touch_writes !== touchWrites && document.getElementById("touch")) {
+      document.getElementById("touch").style.backgroundImage = "url(touch?v=" + state.touch_writes + ")";
+    }
+    touchWrites = state.touch_writes;
+    document.getElementById("deck").style.filter = "brightness(" + Math.max(state.brightness || 100, 20) + "%)";
+  } catch (e) {}
+  setTimeout(poll, 250);
+}
+poll();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,85 @@
+package viamstreamdeck
+
+import (
+	"image/png"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dh1tw/streamdeck"
+
+	"go.viam.com/test"
+)
+
+func TestVirtualDeck(t *testing.T) {
+	ms, err := VirtualModel(&Config{Virtual: &VirtualConfig{Layout: "original"}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ms.Conf.NumButtons(), test.ShouldEqual, 15)
+
+	_, err = VirtualModel(&Config{Virtual: &VirtualConfig{Layout: "nope"}})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	vd, err := NewVirtualDeck(ModelVirtual, "localhost:0")
+	test.That(t, err, test.ShouldBeNil)
+	defer vd.Close()
+
+	events := []streamdeck.Event{}
+	vd.SetBtnEventCb(func(s streamdeck.State, e streamdeck.Event) {
+		events = append(events, e)
+	})
+
+	res, err := http.Get(vd.Addr() + "/")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res.StatusCode, test.ShouldEqual, http.StatusOK)
+	res.Body.Close()
+
+	res, err = http.Get(vd.Addr() + "/key/3")
+	test.That(t, err, test.ShouldBeNil)
+	img, err := png.Decode(res.Body)
+	res.Body.Close()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, img.Bounds().Dx(), test.ShouldEqual, ModelVirtual.Conf.ButtonSize)
+
+	res, err = http.Get(vd.Addr() + "/touch")
+	test.That(t, err, test.ShouldBeNil)
+	img, err = png.Decode(res.Body)
+	res.Body.Close()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, img.Bounds().Size(), test.ShouldResemble, ModelVirtual.TouchStrip)
+
+	for _, u := range []string{"/key/3/press", "/key/3/release", "/dial/1/turn?delta=-2", "/dial/2/press", "/touch/3/swipe_left"} {
+		res, err = http.Post(vd.Addr()+u, "", nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, res.StatusCode, test.ShouldEqual, http.StatusNoContent)
+		res.Body.Close()
+	}
+
+	test.That(t, events, test.ShouldResemble, []streamdeck.Event{
+		{Kind: streamdeck.EventKeyPressed, Which: 3},
+		{Kind: streamdeck.EventKeyReleased, Which: 3},
+		{Kind: streamdeck.EventDialTurn, Which: 1},
+		{Kind: streamdeck.EventDialPressed, Which: 2},
+		{Kind: EventTouchSwipeLeft, Which: 3},
+	})
+
+	res, err = http.Post(vd.Addr()+"/key/99/press", "", nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res.StatusCode, test.ShouldEqual, http.StatusNotFound)
+	res.Body.Close()
+}
+
+func TestVirtualDeckCloseStuck(t *testing.T) {
+	vd, err := NewVirtualDeck(ModelVirtual, "localhost:0")
+	test.That(t, err, test.ShouldBeNil)
+
+	// a request that never finishes keeps the server from shutting down
+	conn, err := net.Dial("tcp", strings.TrimPrefix(vd.Addr(), "http://"))
+	test.That(t, err, test.ShouldBeNil)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\n"))
+	test.That(t, err, test.ShouldBeNil)
+
+	test.That(t, vd.Close(), test.ShouldNotBeNil)
+	test.That(t, vd.Closed(), test.ShouldBeTrue)
+}